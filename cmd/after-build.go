@@ -16,6 +16,8 @@ import (
 var afterBuildOptions = struct {
 	InputType   string
 	Prefix      string
+	Include     []string
+	Exclude     []string
 	BatchSize   int
 	EndpointURL string
 	ReporterID  string
@@ -36,6 +38,8 @@ var afterBuildCmd = &cobra.Command{
 		cf := CoverageFormatter{
 			Prefix:    afterBuildOptions.Prefix,
 			InputType: afterBuildOptions.InputType,
+			Include:   afterBuildOptions.Include,
+			Exclude:   afterBuildOptions.Exclude,
 			writer:    bb,
 		}
 
@@ -63,6 +67,8 @@ func init() {
 	afterBuildCmd.Flags().IntVar(&afterBuildOptions.ExitCode, "exit-code", 0, "exit code of the test run")
 	afterBuildCmd.Flags().StringVarP(&afterBuildOptions.Prefix, "prefix", "p", pwd, "the root directory where the coverage analysis was performed")
 	afterBuildCmd.Flags().StringVarP(&afterBuildOptions.InputType, "coverage-input-type", "t", "", fmt.Sprintf("type of input source to use [%s]", strings.Join(formatterList, ", ")))
+	afterBuildCmd.Flags().StringArrayVar(&afterBuildOptions.Include, "include", []string{}, "only report files matching this glob, e.g. 'src/**'. can be given several times")
+	afterBuildCmd.Flags().StringArrayVar(&afterBuildOptions.Exclude, "exclude", []string{}, "leave out files matching this glob, e.g. 'vendor/**'. can be given several times")
 	afterBuildCmd.Flags().StringVarP(&afterBuildOptions.ReporterID, "id", "r", os.Getenv("CC_TEST_REPORTER_ID"), "reporter identifier")
 	afterBuildCmd.Flags().StringVarP(&afterBuildOptions.EndpointURL, "coverage-endpoint", "e", envy.Get("CC_TEST_REPORTER_COVERAGE_ENDPOINT", "https://api.codeclimate.com/v1/test_reports"), "endpoint to upload coverage information to")
 	afterBuildCmd.Flags().IntVarP(&afterBuildOptions.BatchSize, "batch-size", "s", 500, "batch size for source files")
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const ccConfigFileName = ".cc-test-reporter.yml"
+
+var configPath string
+
+// configSetting is a value a config file can hold. Flags maps the names
+// of the commands that have a flag for it to that flag's name. Env, if
+// set, is the environment variable that also sets it.
+type configSetting struct {
+	Key   string
+	Env   string
+	Flags map[string]string
+}
+
+// configSettings lists every setting a config file can hold. A flag
+// given on the command line beats the environment variable, which beats
+// the config file.
+var configSettings = []configSetting{
+	{Key: "input_type", Flags: map[string]string{"format-coverage": "input-type", "after-build": "coverage-input-type"}},
+	{Key: "coverage_paths"},
+	{Key: "input_root", Flags: map[string]string{"format-coverage": "input-root"}},
+	{Key: "prefix", Flags: map[string]string{"format-coverage": "prefix", "after-build": "prefix"}},
+	{Key: "add_prefix", Flags: map[string]string{"format-coverage": "add-prefix"}},
+	{Key: "path_map", Flags: map[string]string{"format-coverage": "path-map"}},
+	{Key: "path_map_file", Flags: map[string]string{"format-coverage": "path-map-file"}},
+	{Key: "drop_unmatched_paths", Flags: map[string]string{"format-coverage": "drop-unmatched-paths"}},
+	{Key: "include", Flags: map[string]string{"format-coverage": "include", "sum-coverage": "include", "after-build": "include"}},
+	{Key: "exclude", Flags: map[string]string{"format-coverage": "exclude", "sum-coverage": "exclude", "after-build": "exclude"}},
+	{Key: "jobs", Flags: map[string]string{"format-coverage": "jobs"}},
+	{Key: "jacoco_source_path", Env: "JACOCO_SOURCE_PATH"},
+	{Key: "gocov_precise_lines", Env: "GOCOV_PRECISE_LINES"},
+	{Key: "gcov_ignore", Env: "GCOV_IGNORE"},
+	{Key: "id", Env: "CC_TEST_REPORTER_ID", Flags: map[string]string{"upload-coverage": "id", "after-build": "id"}},
+	{Key: "endpoint", Env: "CC_TEST_REPORTER_COVERAGE_ENDPOINT", Flags: map[string]string{"upload-coverage": "endpoint", "after-build": "coverage-endpoint"}},
+	{Key: "batch_size", Flags: map[string]string{"upload-coverage": "batch-size", "after-build": "batch-size"}},
+	{Key: "insecure", Flags: map[string]string{"upload-coverage": "insecure", "after-build": "insecure"}},
+}
+
+// projectConfig holds the values read from the config file, as strings
+// the way they'd be given on the command line.
+type projectConfig struct {
+	Path   string
+	Values map[string][]string
+	// the settings whose flags were set from the file
+	applied map[string]bool
+}
+
+var loadedConfig = projectConfig{Values: map[string][]string{}}
+
+// findConfigFile looks for a config file in the working directory and
+// each of its parents, stopping at the root of the git repo.
+func findConfigFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(dir, ccConfigFileName)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (projectConfig, error) {
+	c := projectConfig{Path: path, Values: map[string][]string{}, applied: map[string]bool{}}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, errors.WithStack(err)
+	}
+
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return c, errors.Wrapf(err, "could not parse %s", path)
+	}
+
+	known := map[string]bool{}
+	for _, s := range configSettings {
+		known[s.Key] = true
+	}
+
+	for k, v := range raw {
+		if !known[k] {
+			return c, errors.Errorf("unknown setting %q in %s", k, path)
+		}
+		switch vv := v.(type) {
+		case nil:
+		case []interface{}:
+			values := []string{}
+			for _, item := range vv {
+				values = append(values, fmt.Sprint(item))
+			}
+			c.Values[k] = values
+		case map[string]interface{}:
+			return c, errors.Errorf("setting %q in %s must be a value or a list", k, path)
+		default:
+			c.Values[k] = []string{fmt.Sprint(vv)}
+		}
+	}
+	return c, nil
+}
+
+// applyConfig loads the config file and uses its values for the flags of
+// cmd that weren't given and have no environment variable set.
+func applyConfig(cmd *cobra.Command) error {
+	path := configPath
+	if path == "" {
+		path = findConfigFile()
+	}
+	if path == "" {
+		return nil
+	}
+
+	c, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("using config file %s", path)
+	loadedConfig = c
+
+	for _, s := range configSettings {
+		values, inFile := c.Values[s.Key]
+		if !inFile {
+			continue
+		}
+
+		if s.Env != "" {
+			if _, err := envy.MustGet(s.Env); err == nil {
+				continue
+			}
+		}
+
+		flag, ok := s.Flags[cmd.Name()]
+		if !ok {
+			// settings without a flag are read from the environment
+			if s.Env != "" {
+				envy.Set(s.Env, strings.Join(values, " "))
+				c.applied[s.Key] = true
+			}
+			continue
+		}
+
+		f := cmd.Flags().Lookup(flag)
+		if f == nil || f.Changed {
+			continue
+		}
+		for _, v := range values {
+			if err := cmd.Flags().Set(flag, v); err != nil {
+				return errors.Wrapf(err, "invalid value for %q in %s", s.Key, path)
+			}
+		}
+		c.applied[s.Key] = true
+		logrus.Debugf("setting --%s from %s in %s", flag, s.Key, path)
+	}
+	return nil
+}
+
+type configValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// effectiveConfig returns the value of each setting for cmd, along with
+// where it came from.
+func effectiveConfig(cmd *cobra.Command) map[string]configValue {
+	res := map[string]configValue{}
+	for _, s := range configSettings {
+		if flag, ok := s.Flags[cmd.Name()]; ok {
+			if f := cmd.Flags().Lookup(flag); f != nil && f.Changed && !loadedConfig.applied[s.Key] {
+				res[s.Key] = configValue{Value: f.Value.String(), Source: "flag --" + flag}
+				continue
+			}
+		}
+		if s.Env != "" && !loadedConfig.applied[s.Key] {
+			if v, err := envy.MustGet(s.Env); err == nil {
+				res[s.Key] = configValue{Value: v, Source: "env " + s.Env}
+				continue
+			}
+		}
+		if values, ok := loadedConfig.Values[s.Key]; ok {
+			res[s.Key] = configValue{Value: strings.Join(values, " "), Source: "file " + loadedConfig.Path}
+			continue
+		}
+		res[s.Key] = configValue{Value: settingDefault(s), Source: "default"}
+	}
+	return res
+}
+
+// settingDefault returns the default of the first flag for the setting.
+func settingDefault(s configSetting) string {
+	commands := []string{}
+	for c := range s.Flags {
+		commands = append(commands, c)
+	}
+	sort.Strings(commands)
+	for _, c := range commands {
+		for _, sub := range RootCmd.Commands() {
+			if sub.Name() != c {
+				continue
+			}
+			if f := sub.Flags().Lookup(s.Flags[c]); f != nil {
+				return f.DefValue
+			}
+		}
+	}
+	return ""
+}
+
+func printConfig(config map[string]configValue) {
+	for _, s := range configSettings {
+		v := config[s.Key]
+		fmt.Printf("%s=%s (%s)\n", s.Key, v.Value, v.Source)
+	}
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&configPath, "config", "", fmt.Sprintf("path to the config file (defaults to %s in the repo root)", ccConfigFileName))
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobuffalo/envy"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ccConfigFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func Test_loadConfig(t *testing.T) {
+	r := require.New(t)
+
+	c, err := loadConfig(writeConfig(t, `
+input_type: lcov
+coverage_paths:
+  - packages/*/coverage/lcov.info
+batch_size: 100
+insecure: true
+path_map:
+  - "^/app/=>"
+  - "^lib/=>src/"
+`))
+	r.NoError(err)
+	r.Equal([]string{"lcov"}, c.Values["input_type"])
+	r.Equal([]string{"packages/*/coverage/lcov.info"}, c.Values["coverage_paths"])
+	r.Equal([]string{"100"}, c.Values["batch_size"])
+	r.Equal([]string{"true"}, c.Values["insecure"])
+	r.Equal([]string{"^/app/=>", "^lib/=>src/"}, c.Values["path_map"])
+
+	_, err = loadConfig(writeConfig(t, "batch-size: 100\n"))
+	r.Error(err)
+	r.Contains(err.Error(), `unknown setting "batch-size"`)
+
+	_, err = loadConfig(writeConfig(t, "prefix:\n  foo: bar\n"))
+	r.Error(err)
+}
+
+func Test_applyConfig_Precedence(t *testing.T) {
+	r := require.New(t)
+
+	defer func() {
+		configPath = ""
+		loadedConfig = projectConfig{Values: map[string][]string{}}
+	}()
+	configPath = writeConfig(t, `
+id: from-file
+endpoint: https://file.example.com
+batch_size: 100
+insecure: true
+jacoco_source_path: src/main/java
+`)
+
+	envy.Temp(func() {
+		envy.Set("CC_TEST_REPORTER_ID", "from-env")
+
+		cmd := &cobra.Command{Use: "upload-coverage"}
+		cmd.Flags().StringP("id", "r", "from-env", "")
+		cmd.Flags().String("endpoint", "https://default.example.com", "")
+		cmd.Flags().Int("batch-size", 500, "")
+		cmd.Flags().Bool("insecure", false, "")
+		r.NoError(cmd.Flags().Parse([]string{"--batch-size", "10"}))
+
+		r.NoError(applyConfig(cmd))
+
+		// the flag wins over the file
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		r.Equal(10, batchSize)
+		// the environment wins over the file
+		id, _ := cmd.Flags().GetString("id")
+		r.Equal("from-env", id)
+		// the file wins over the default
+		endpoint, _ := cmd.Flags().GetString("endpoint")
+		r.Equal("https://file.example.com", endpoint)
+		insecure, _ := cmd.Flags().GetBool("insecure")
+		r.True(insecure)
+		// settings without a flag go through the environment
+		r.Equal("src/main/java", envy.Get("JACOCO_SOURCE_PATH", ""))
+
+		config := effectiveConfig(cmd)
+		r.Equal(configValue{Value: "10", Source: "flag --batch-size"}, config["batch_size"])
+		r.Equal(configValue{Value: "from-env", Source: "env CC_TEST_REPORTER_ID"}, config["id"])
+		r.Equal(configValue{Value: "https://file.example.com", Source: "file " + configPath}, config["endpoint"])
+		r.Equal(configValue{Value: "src/main/java", Source: "file " + configPath}, config["jacoco_source_path"])
+	})
+}
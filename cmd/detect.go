@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/codeclimate/test-reporter/formatters/xccov"
+	"github.com/pkg/errors"
+)
+
+// how sure we are that a file found by a formatter's search paths is
+// actually in that formatter's format
+const (
+	confidenceNone      = 0.0
+	confidenceUnknown   = 0.5
+	confidenceConfirmed = 1.0
+)
+
+// a map of the checks that confirm a file is in a formatter's format.
+// formatters without one are trusted at confidenceUnknown.
+var formatterSniffers = map[string]func(path string) float64{
+	"clover":    sniffXML("coverage", "project"),
+	"cobertura": sniffXML("coverage", "sources", "packages"),
+	"coverage.py": func(path string) float64 {
+		// the XML report, or the one written by "coverage json"
+		if c := sniffXML("coverage", "sources", "packages")(path); c != confidenceNone {
+			return c
+		}
+		return sniffJSON("meta", "files")(path)
+	},
+	"excoveralls": sniffJSON("source_files"),
+	"gcov": func(string) float64 {
+		// gcov only finds files by their .gcov and .gcov.json.gz extensions,
+		// at the top of ./ when detecting
+		return confidenceConfirmed
+	},
+	"gocov": sniffLines("mode:"),
+	"gocovdata": func(string) float64 {
+		// gocovdata only finds directories holding a covmeta file
+		return confidenceConfirmed
+	},
+	"istanbul": func(path string) float64 {
+		keys, err := formatters.JSONKeys(path)
+		if err != nil {
+			return confidenceNone
+		}
+		// the top level is keyed by file, each with a map of statements
+		for _, k := range keys {
+			if strings.HasSuffix(k, ".statementMap") {
+				return confidenceConfirmed
+			}
+		}
+		return confidenceNone
+	},
+	"jacoco":    sniffXML("report"),
+	"lcov":      sniffLines("TN:", "SF:"),
+	"lcov-json": sniffJSON("data", "type"),
+	"simplecov": func(path string) float64 {
+		keys, err := formatters.JSONKeys(path)
+		if err != nil {
+			return confidenceNone
+		}
+		for _, k := range keys {
+			// "coverage" at the top level for the JSON formatter, nested
+			// under each command name for the legacy resultset
+			if k == "coverage" || strings.HasSuffix(k, ".coverage") {
+				return confidenceConfirmed
+			}
+		}
+		return confidenceNone
+	},
+	"xccov": func(path string) float64 {
+		// the report of xccov view --report, or per-line coverage
+		if xccov.IsLineCoverage(path) {
+			return confidenceConfirmed
+		}
+		return sniffJSON("targets")(path)
+	},
+	"dotcover":  sniffXML("Root"),
+	"opencover": sniffXML("CoverageSession"),
+	// the generic format shares its root element with clover and cobertura
+	"sonar-generic": sniffXML("coverage", "file"),
+	"phpunit-xml": func(path string) float64 {
+		// phpunit-xml finds report directories, which have an index.xml
+		return sniffXML("phpunit", "build", "project")(filepath.Join(path, "index.xml"))
+	},
+	"v8": func(path string) float64 {
+		// v8 finds NODE_V8_COVERAGE directories as well as single files
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			files, _ := filepath.Glob(filepath.Join(path, "*.json"))
+			if len(files) == 0 {
+				return confidenceNone
+			}
+			path = files[0]
+		}
+		return sniffJSON("result")(path)
+	},
+}
+
+// sniffXML confirms a file whose root element is root and, if children
+// are given, whose first child element is one of them.
+func sniffXML(root string, children ...string) func(string) float64 {
+	return func(path string) float64 {
+		names, err := formatters.XMLElements(path, 2)
+		if err != nil || len(names) == 0 || names[0] != root {
+			return confidenceNone
+		}
+		if len(children) == 0 {
+			return confidenceConfirmed
+		}
+		if len(names) < 2 {
+			return confidenceUnknown
+		}
+		for _, c := range children {
+			if names[1] == c {
+				return confidenceConfirmed
+			}
+		}
+		return confidenceNone
+	}
+}
+
+// sniffJSON confirms a file whose top level object has all of the keys.
+func sniffJSON(required ...string) func(string) float64 {
+	return func(path string) float64 {
+		keys, err := formatters.JSONKeys(path)
+		if err != nil {
+			return confidenceNone
+		}
+		found := map[string]bool{}
+		for _, k := range keys {
+			found[k] = true
+		}
+		for _, k := range required {
+			if !found[k] {
+				return confidenceNone
+			}
+		}
+		return confidenceConfirmed
+	}
+}
+
+// sniffLines confirms a file with a line starting with one of the
+// prefixes near its beginning.
+func sniffLines(prefixes ...string) func(string) float64 {
+	return func(path string) float64 {
+		ok, err := formatters.HasLinePrefix(path, 10, prefixes...)
+		if err != nil || !ok {
+			return confidenceNone
+		}
+		return confidenceConfirmed
+	}
+}
+
+type formatterCandidate struct {
+	name       string
+	path       string
+	formatter  formatters.Formatter
+	confidence float64
+}
+
+func (c formatterCandidate) String() string {
+	return fmt.Sprintf("%s (%s)", c.name, c.path)
+}
+
+// detectFormatter checks the default search paths of every formatter, in
+// formatterList order, and picks the one whose file most likely is in its
+// format. The formatters pass opts on to NewSourceFile. It is an error for several formatters to be equally likely.
+func detectFormatter(opts formatters.SourceFileOptions) (formatters.Formatter, error) {
+	candidates := []formatterCandidate{}
+	best := confidenceNone
+	for _, n := range formatterList {
+		logrus.Debugf("checking %s formatter", n)
+		f := formatterMap[n](opts)
+		p, err := f.Search()
+		if err != nil {
+			continue
+		}
+
+		confidence := confidenceUnknown
+		if sniff, ok := formatterSniffers[n]; ok {
+			confidence = sniff(p)
+		}
+		logrus.Debugf("found file %s for %s formatter (confidence %.2f)", p, n, confidence)
+		if confidence == confidenceNone {
+			continue
+		}
+
+		candidates = append(candidates, formatterCandidate{name: n, path: p, formatter: f, confidence: confidence})
+		if confidence > best {
+			best = confidence
+		}
+	}
+
+	top := []formatterCandidate{}
+	for _, c := range candidates {
+		if c.confidence == best {
+			top = append(top, c)
+		}
+	}
+
+	switch len(top) {
+	case 0:
+		return nil, errors.Errorf("could not find any viable formatter. available formatters: %s", strings.Join(formatterList, ", "))
+	case 1:
+		logrus.Debugf("using %s formatter", top[0].name)
+		return top[0].formatter, nil
+	default:
+		choices := []string{}
+		for _, c := range top {
+			choices = append(choices, c.String())
+		}
+		return nil, errors.Errorf("found coverage files for several formatters: %s. please pick one using the --input-type flag", strings.Join(choices, ", "))
+	}
+}
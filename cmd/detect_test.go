@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/codeclimate/test-reporter/formatters/xccov"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_formatterSniffers(t *testing.T) {
+	r := require.New(t)
+
+	tt := []struct {
+		formatter string
+		path      string
+		expected  float64
+	}{
+		{"clover", "../formatters/clover/example.xml", confidenceConfirmed},
+		{"clover", "../formatters/cobertura/example.xml", confidenceNone},
+		{"cobertura", "../formatters/cobertura/example.xml", confidenceConfirmed},
+		{"cobertura", "../formatters/sonargeneric/example.xml", confidenceNone},
+		{"clover", "../formatters/sonargeneric/example.xml", confidenceNone},
+		{"sonar-generic", "../formatters/sonargeneric/example.xml", confidenceConfirmed},
+		{"sonar-generic", "../formatters/clover/example.xml", confidenceNone},
+		{"phpunit-xml", "../formatters/phpunitxml/example", confidenceConfirmed},
+		{"phpunit-xml", "../formatters/clover", confidenceNone},
+		{"v8", "../formatters/v8/example/coverage", confidenceConfirmed},
+		{"v8", "../formatters/istanbul/coverage-final.json", confidenceNone},
+		{"coverage.py", "../formatters/coveragepy/example.xml", confidenceConfirmed},
+		{"coverage.py", "../formatters/coveragepy/example_report.json", confidenceConfirmed},
+		{"coverage.py", "../formatters/istanbul/coverage-final.json", confidenceNone},
+		{"jacoco", "../formatters/jacoco/example.xml", confidenceConfirmed},
+		{"dotcover", "../formatters/dotcover/example.xml", confidenceConfirmed},
+		{"dotcover", "../formatters/opencover/example.xml", confidenceNone},
+		{"opencover", "../formatters/opencover/example.xml", confidenceConfirmed},
+		{"opencover", "../formatters/dotcover/example.xml", confidenceNone},
+		{"excoveralls", "../formatters/excoveralls/excoveralls_example.json", confidenceConfirmed},
+		{"lcov", "../formatters/lcov/example.info", confidenceConfirmed},
+		{"lcov", "../formatters/gocov/example.out", confidenceNone},
+		{"gocov", "../formatters/gocov/example.out", confidenceConfirmed},
+		{"lcov-json", "../formatters/lcovjson/lcovjson_example.json", confidenceConfirmed},
+		{"istanbul", "../formatters/istanbul/coverage-final.json", confidenceConfirmed},
+		{"istanbul", "../formatters/lcovjson/lcovjson_example.json", confidenceNone},
+		{"simplecov", "../formatters/istanbul/coverage-final.json", confidenceNone},
+		{"simplecov", "../formatters/simplecov/simplecov-simple-example.json", confidenceConfirmed},
+		{"simplecov", "../formatters/simplecov/simplecov-example-legacy-resultset.json", confidenceConfirmed},
+		{"simplecov", "../formatters/xccov/xccov_example.json", confidenceNone},
+		{"xccov", "../formatters/xccov/xccov_example.json", confidenceConfirmed},
+		{"xccov", "../formatters/simplecov/simplecov-simple-example.json", confidenceNone},
+		{"xccov", "../formatters/xccov/xccov_lines_example.json", confidenceConfirmed},
+		{"xccov", "../formatters/istanbul/coverage-final.json", confidenceNone},
+		{"simplecov", "../formatters/xccov/xccov_lines_example.json", confidenceNone},
+		{"istanbul", "../formatters/xccov/xccov_lines_example.json", confidenceNone},
+	}
+
+	for _, tc := range tt {
+		r.Equal(tc.expected, formatterSniffers[tc.formatter](tc.path), "%s: %s", tc.formatter, tc.path)
+	}
+}
+
+func Test_detectFormatter(t *testing.T) {
+	r := require.New(t)
+
+	pwd, err := os.Getwd()
+	r.NoError(err)
+	dir := t.TempDir()
+	r.NoError(os.Chdir(dir))
+	defer os.Chdir(pwd)
+
+	copyFixture := func(from, to string) {
+		b, err := os.ReadFile(filepath.Join(pwd, from))
+		r.NoError(err)
+		r.NoError(os.MkdirAll(filepath.Dir(to), 0755))
+		r.NoError(os.WriteFile(to, b, 0644))
+	}
+
+	_, err = detectFormatter(formatters.SourceFileOptions{})
+	r.Error(err)
+	r.Contains(err.Error(), "could not find any viable formatter")
+
+	// an xccov report where simplecov would also look for one
+	copyFixture("../formatters/xccov/xccov_example.json", "coverage.json")
+	f, err := detectFormatter(formatters.SourceFileOptions{})
+	r.NoError(err)
+	r.IsType(&xccov.Formatter{}, f)
+
+	// a .gcov file deeper in the tree isn't taken for the coverage
+	copyFixture("../formatters/gcov/examples/hamming.c.gcov", "third_party/hamming/hamming.c.gcov")
+	f, err = detectFormatter(formatters.SourceFileOptions{})
+	r.NoError(err)
+	r.IsType(&xccov.Formatter{}, f)
+
+	copyFixture("../formatters/clover/example.xml", "clover.xml")
+	_, err = detectFormatter(formatters.SourceFileOptions{})
+	r.Error(err)
+	r.Contains(err.Error(), "clover (clover.xml), xccov (coverage.json)")
+}
@@ -14,11 +14,29 @@ var envCmd = &cobra.Command{
 	Use:   "env",
 	Short: "Infer and output information about the environment the reporter is running in.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		e, err := env.New()
+		f, err := cmd.Flags().GetString("format")
 		if err != nil {
 			return err
 		}
-		f, err := cmd.Flags().GetString("format")
+		showConfig, err := cmd.Flags().GetBool("show-config")
+		if err != nil {
+			return err
+		}
+		if showConfig {
+			config := effectiveConfig(cmd)
+			switch f {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				// path map rules are full of "=>"
+				enc.SetEscapeHTML(false)
+				enc.Encode(config)
+			default:
+				printConfig(config)
+			}
+			return nil
+		}
+
+		e, err := env.New()
 		if err != nil {
 			return err
 		}
@@ -34,5 +52,6 @@ var envCmd = &cobra.Command{
 
 func init() {
 	envCmd.Flags().StringP("format", "f", "string", "formats the output")
+	envCmd.Flags().Bool("show-config", false, "output the effective configuration and where each value came from")
 	RootCmd.AddCommand(envCmd)
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -15,57 +17,85 @@ import (
 	"github.com/codeclimate/test-reporter/formatters/excoveralls"
 	"github.com/codeclimate/test-reporter/formatters/gcov"
 	"github.com/codeclimate/test-reporter/formatters/gocov"
+	"github.com/codeclimate/test-reporter/formatters/gocovdata"
+	"github.com/codeclimate/test-reporter/formatters/istanbul"
 	"github.com/codeclimate/test-reporter/formatters/jacoco"
 	"github.com/codeclimate/test-reporter/formatters/lcov"
 	"github.com/codeclimate/test-reporter/formatters/lcovjson"
+	"github.com/codeclimate/test-reporter/formatters/opencover"
+	"github.com/codeclimate/test-reporter/formatters/phpunitxml"
 	"github.com/codeclimate/test-reporter/formatters/simplecov"
+	"github.com/codeclimate/test-reporter/formatters/sonargeneric"
+	"github.com/codeclimate/test-reporter/formatters/v8"
 	"github.com/codeclimate/test-reporter/formatters/xccov"
-	"github.com/gobuffalo/envy"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 type CoverageFormatter struct {
-	CoveragePath string
-	In           formatters.Formatter
-	InputType    string
-	Output       string
-	Prefix       string
-	AddPrefix    string
-	writer       io.Writer
+	CoveragePaths []string
+	In            formatters.Formatter
+	InputType     string
+	InputRoot     string
+	Output        string
+	Prefix        string
+	AddPrefix     string
+	PathMap       []string
+	PathMapFile   string
+	DropUnmatched bool
+	Include       []string
+	Exclude       []string
+	Jobs          int
+	writer        io.Writer
 }
 
 var formatOptions = CoverageFormatter{}
 
 // a prioritized list of the formatters to use
-var formatterList = []string{"clover", "cobertura", "coverage.py", "excoveralls", "gcov", "gocov", "jacoco", "lcov", "lcov-json", "simplecov", "xccov", "dotcover"}
-
-// a map of the formatters to use
-var formatterMap = map[string]formatters.Formatter{
-	"clover":      &clover.Formatter{},
-	"cobertura":   &cobertura.Formatter{},
-	"coverage.py": &coveragepy.Formatter{},
-	"excoveralls": &excoveralls.Formatter{},
-	"gcov":        &gcov.Formatter{},
-	"gocov":       &gocov.Formatter{},
-	"jacoco":      &jacoco.Formatter{},
-	"lcov":        &lcov.Formatter{},
-	"lcov-json":   &lcovjson.Formatter{},
-	"simplecov":   &simplecov.Formatter{},
-	"xccov":       &xccov.Formatter{},
-	"dotcover":    &dotcover.Formatter{},
+var formatterList = []string{"clover", "cobertura", "coverage.py", "excoveralls", "gcov", "gocov", "gocovdata", "istanbul", "jacoco", "lcov", "lcov-json", "simplecov", "xccov", "dotcover", "opencover", "sonar-generic", "phpunit-xml", "v8"}
+
+// a map of the formatters to use. Each call returns a new formatter so
+// several coverage files of the same type can be formatted at once.
+var formatterMap = map[string]func(formatters.SourceFileOptions) formatters.Formatter{
+	"clover":        func(o formatters.SourceFileOptions) formatters.Formatter { return &clover.Formatter{Options: o} },
+	"cobertura":     func(o formatters.SourceFileOptions) formatters.Formatter { return &cobertura.Formatter{Options: o} },
+	"coverage.py":   func(o formatters.SourceFileOptions) formatters.Formatter { return &coveragepy.Formatter{Options: o} },
+	"excoveralls":   func(o formatters.SourceFileOptions) formatters.Formatter { return &excoveralls.Formatter{Options: o} },
+	"gcov":          func(o formatters.SourceFileOptions) formatters.Formatter { return &gcov.Formatter{Options: o} },
+	"gocov":         func(o formatters.SourceFileOptions) formatters.Formatter { return &gocov.Formatter{Options: o} },
+	"gocovdata":     func(o formatters.SourceFileOptions) formatters.Formatter { return &gocovdata.Formatter{Options: o} },
+	"istanbul":      func(o formatters.SourceFileOptions) formatters.Formatter { return &istanbul.Formatter{Options: o} },
+	"jacoco":        func(o formatters.SourceFileOptions) formatters.Formatter { return &jacoco.Formatter{Options: o} },
+	"lcov":          func(o formatters.SourceFileOptions) formatters.Formatter { return &lcov.Formatter{Options: o} },
+	"lcov-json":     func(o formatters.SourceFileOptions) formatters.Formatter { return &lcovjson.Formatter{Options: o} },
+	"simplecov":     func(o formatters.SourceFileOptions) formatters.Formatter { return &simplecov.Formatter{Options: o} },
+	"xccov":         func(o formatters.SourceFileOptions) formatters.Formatter { return &xccov.Formatter{Options: o} },
+	"dotcover":      func(o formatters.SourceFileOptions) formatters.Formatter { return &dotcover.Formatter{Options: o} },
+	"opencover":     func(o formatters.SourceFileOptions) formatters.Formatter { return &opencover.Formatter{Options: o} },
+	"sonar-generic": func(o formatters.SourceFileOptions) formatters.Formatter { return &sonargeneric.Formatter{Options: o} },
+	"phpunit-xml":   func(o formatters.SourceFileOptions) formatters.Formatter { return &phpunitxml.Formatter{Options: o} },
+	"v8":            func(o formatters.SourceFileOptions) formatters.Formatter { return &v8.Formatter{Options: o} },
+}
+
+// the formatters that merge all the paths they're given themselves,
+// rather than formatting each on its own
+var mergingFormatters = map[string]bool{
+	"gocovdata": true,
 }
 
 // formatCoverageCmd represents the format command
 var formatCoverageCmd = &cobra.Command{
-	Use:   "format-coverage [coverage file]",
+	Use:   "format-coverage [coverage files]",
 	Short: "Locate, parse, and re-format supported coverage sources.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = loadedConfig.Values["coverage_paths"]
+		}
 		if len(args) != 0 {
-			logrus.Debugf("coverage path %s", args[0])
-			formatOptions.CoveragePath = args[0]
+			logrus.Debugf("coverage paths %s", strings.Join(args, ", "))
+			formatOptions.CoveragePaths = args
 			if formatOptions.InputType == "" {
-				return errors.WithStack(errors.Errorf("please specify the format of the coverage file \"%s\" using the --input-type flag", formatOptions.CoveragePath))
+				return errors.WithStack(errors.Errorf("please specify the format of the coverage file \"%s\" using the --input-type flag", strings.Join(formatOptions.CoveragePaths, ", ")))
 			}
 		}
 		return runFormatter(formatOptions)
@@ -73,16 +103,47 @@ var formatCoverageCmd = &cobra.Command{
 }
 
 func runFormatter(formatOptions CoverageFormatter) error {
-	envy.Set("PREFIX", formatOptions.Prefix)
-	envy.Set("ADD_PREFIX", formatOptions.AddPrefix)
+	pathMapFiles := []string{}
+	if formatOptions.PathMapFile != "" {
+		pathMapFiles = append(pathMapFiles, formatOptions.PathMapFile)
+	}
+	pathMap, err := formatters.ParsePathMap(formatOptions.PathMap, pathMapFiles...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if formatOptions.DropUnmatched && len(pathMap.Rules) == 0 {
+		return errors.New("--drop-unmatched-paths needs at least one --path-map rule")
+	}
+	pathMap.DropUnmatched = formatOptions.DropUnmatched
+
+	filter, err := formatters.NewFileFilter(formatOptions.Include, formatOptions.Exclude)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	opts := formatters.SourceFileOptions{
+		PathMap:   pathMap,
+		Prefix:    formatOptions.Prefix,
+		AddPrefix: formatOptions.AddPrefix,
+		Filter:    filter,
+		// blob ids are computed in bulk once the coverage is formatted
+		DeferBlobs: formatOptions.Jobs > 0,
+	}
 
 	// if a type is specified use that
 	if formatOptions.InputType != "" {
-		if f, ok := formatterMap[formatOptions.InputType]; ok {
+		if newFormatter, ok := formatterMap[formatOptions.InputType]; ok {
 			logrus.Debugf("using formatter %s", formatOptions.InputType)
-			_, err := f.Search(formatOptions.CoveragePath)
+			paths, err := expandCoveragePaths(formatOptions.CoveragePaths)
 			if err != nil {
-				logrus.Errorf("could not find coverage file %s\n%s", formatOptions.CoveragePath, err)
+				return errors.WithStack(err)
+			}
+			f := newFormatter(opts)
+			if len(paths) > 1 && !mergingFormatters[formatOptions.InputType] || formatOptions.InputRoot != "" {
+				f = &multiFormatter{newFormatter: newFormatter, options: opts, inputRoot: formatOptions.InputRoot}
+			}
+			_, err = f.Search(paths...)
+			if err != nil {
+				logrus.Errorf("could not find coverage file %s\n%s", strings.Join(paths, ", "), err)
 				return errors.WithStack(err)
 			}
 			formatOptions.In = f
@@ -91,15 +152,11 @@ func runFormatter(formatOptions CoverageFormatter) error {
 		}
 	} else {
 		logrus.Debug("searching for a formatter to use")
-		// else start searching for files:
-		for n, f := range formatterMap {
-			logrus.Debugf("checking %s formatter", n)
-			if p, err := f.Search(); err == nil {
-				logrus.Debugf("found file %s for %s formatter", p, n)
-				formatOptions.In = f
-				break
-			}
+		f, err := detectFormatter(opts)
+		if err != nil {
+			return errors.WithStack(err)
 		}
+		formatOptions.In = f
 	}
 
 	if formatOptions.In == nil {
@@ -109,6 +166,103 @@ func runFormatter(formatOptions CoverageFormatter) error {
 	return formatOptions.Save()
 }
 
+// expandCoveragePaths expands any globs in the coverage paths given on
+// the command line. Plain paths are kept as is so the formatter can
+// report them if they don't exist.
+func expandCoveragePaths(args []string) ([]string, error) {
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return paths, errors.WithStack(err)
+			}
+			if len(matches) == 0 {
+				return paths, errors.Errorf("could not find any coverage files matching %s", arg)
+			}
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths, nil
+}
+
+type coverageInput struct {
+	path      string
+	formatter formatters.Formatter
+}
+
+// multiFormatter formats several coverage files with formatters of the
+// same type and merges the results into a single report.
+type multiFormatter struct {
+	newFormatter func(formatters.SourceFileOptions) formatters.Formatter
+	options      formatters.SourceFileOptions
+	inputRoot    string
+	inputs       []coverageInput
+}
+
+func (m *multiFormatter) Search(paths ...string) (string, error) {
+	for _, p := range paths {
+		// relative source paths in each file are resolved against a
+		// directory next to that file
+		opts := m.options
+		if m.inputRoot != "" {
+			opts.Root = filepath.Join(filepath.Dir(p), m.inputRoot)
+		}
+		f := m.newFormatter(opts)
+		if _, err := f.Search(p); err != nil {
+			return "", errors.WithStack(err)
+		}
+		m.inputs = append(m.inputs, coverageInput{path: p, formatter: f})
+	}
+	return strings.Join(paths, ", "), nil
+}
+
+func (m *multiFormatter) Format() (formatters.Report, error) {
+	rep := formatters.Report{}
+	for i, in := range m.inputs {
+		logrus.Debugf("formatting coverage file %s", in.path)
+		r, err := in.formatter.Format()
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		if i == 0 {
+			rep = r
+			continue
+		}
+		err = rep.Merge(&r)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+	}
+
+	// set mode Go counters only tell whether blocks ran, so the runs of
+	// several directories are merged as go tool covdata does, rather than
+	// added up
+	mode := ""
+	for _, in := range m.inputs {
+		g, ok := in.formatter.(*gocovdata.Formatter)
+		if !ok {
+			continue
+		}
+		if mode != "" && g.Mode != mode {
+			return rep, errors.Errorf("can't merge %s coverage from %s with %s coverage", g.Mode, in.path, mode)
+		}
+		mode = g.Mode
+	}
+	if mode == "set" {
+		rep.LimitHits(1)
+	}
+	return rep, nil
+}
+
 func (f CoverageFormatter) Save() error {
 	rep, err := f.In.Format()
 	if err != nil {
@@ -118,6 +272,12 @@ func (f CoverageFormatter) Save() error {
 	if len(rep.SourceFiles) == 0 {
 		return errors.WithStack(errors.New("could not find coverage info for source files"))
 	}
+	rep.Environment.Prefix = f.Prefix
+
+	err = rep.ResolveBlobIDs(f.Jobs)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
 	if f.writer == nil {
 		f.writer, err = writer(formatOptions.Output)
@@ -137,7 +297,14 @@ func init() {
 	pwd, _ := os.Getwd()
 	formatCoverageCmd.Flags().StringVarP(&formatOptions.Prefix, "prefix", "p", pwd, "the root directory where the coverage analysis was performed")
 	formatCoverageCmd.Flags().StringVar(&formatOptions.AddPrefix, "add-prefix", "", "add this prefix to file paths")
+	formatCoverageCmd.Flags().StringArrayVar(&formatOptions.PathMap, "path-map", []string{}, "rewrite file paths matching a regex, as 'regex=>replacement'. can be given several times, rules are applied in order")
+	formatCoverageCmd.Flags().StringVar(&formatOptions.PathMapFile, "path-map-file", "", "read --path-map rules from a file, one per line")
+	formatCoverageCmd.Flags().BoolVar(&formatOptions.DropUnmatched, "drop-unmatched-paths", false, "leave out files whose paths no --path-map rule matched")
+	formatCoverageCmd.Flags().StringArrayVar(&formatOptions.Include, "include", []string{}, "only report files matching this glob, e.g. 'src/**'. can be given several times")
+	formatCoverageCmd.Flags().StringArrayVar(&formatOptions.Exclude, "exclude", []string{}, "leave out files matching this glob, e.g. 'vendor/**'. can be given several times")
+	formatCoverageCmd.Flags().StringVar(&formatOptions.InputRoot, "input-root", "", "resolve relative file paths against this directory, relative to each coverage file")
 	formatCoverageCmd.Flags().StringVarP(&formatOptions.Output, "output", "o", ccDefaultCoveragePath, "output path")
+	formatCoverageCmd.Flags().IntVarP(&formatOptions.Jobs, "jobs", "j", runtime.NumCPU(), "number of files to compute git blob ids for in parallel")
 	formatCoverageCmd.Flags().StringVarP(&formatOptions.InputType, "input-type", "t", "", fmt.Sprintf("type of input source to use [%s]", strings.Join(formatterList, ", ")))
 	RootCmd.AddCommand(formatCoverageCmd)
 }
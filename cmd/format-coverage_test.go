@@ -1,9 +1,20 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/codeclimate/test-reporter/formatters/clover"
+	"github.com/gobuffalo/envy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,3 +28,197 @@ func Test_CoverageFormatter_Save_Fail_On_Empty_Report(t *testing.T) {
 	r.Error(err)
 	r.Equal("could not find coverage info for source files", err.Error())
 }
+
+func Test_runFormatter_Multiple_Inputs(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	dir := t.TempDir()
+	for _, pkg := range []string{"a", "b"} {
+		r.NoError(os.MkdirAll(filepath.Join(dir, "packages", pkg, "coverage"), 0755))
+		info := "SF:src/index.js\nDA:1,1\nDA:2,0\nend_of_record\n"
+		r.NoError(os.WriteFile(filepath.Join(dir, "packages", pkg, "coverage", "lcov.info"), []byte(info), 0644))
+	}
+
+	bb := &bytes.Buffer{}
+	envy.Temp(func() {
+		err := runFormatter(CoverageFormatter{
+			CoveragePaths: []string{filepath.Join(dir, "packages", "*", "coverage", "lcov.info")},
+			InputType:     "lcov",
+			InputRoot:     "..",
+			Prefix:        dir,
+			writer:        bb,
+		})
+		r.NoError(err)
+	})
+
+	rep := formatters.Report{SourceFiles: formatters.SourceFiles{}}
+	r.NoError(json.Unmarshal(bb.Bytes(), &rep))
+	r.Len(rep.SourceFiles, 2)
+	r.Contains(rep.SourceFiles, filepath.Join("packages", "a", "src", "index.js"))
+	r.Contains(rep.SourceFiles, filepath.Join("packages", "b", "src", "index.js"))
+	r.Equal(4, rep.LineCounts.Total)
+	r.Equal(2, rep.LineCounts.Covered)
+}
+
+func Test_expandCoveragePaths(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	for _, name := range []string{"a/clover.xml", "b/clover.xml", "b/coverage.json"} {
+		p := filepath.Join(dir, name)
+		r.NoError(os.MkdirAll(filepath.Dir(p), 0755))
+		r.NoError(os.WriteFile(p, []byte("<coverage/>"), 0644))
+	}
+
+	a := filepath.Join(dir, "a", "clover.xml")
+	b := filepath.Join(dir, "b", "clover.xml")
+	paths, err := expandCoveragePaths([]string{filepath.Join(dir, "*", "*.xml"), "missing.xml", a})
+	r.NoError(err)
+	r.Equal([]string{a, b, "missing.xml"}, paths)
+
+	_, err = expandCoveragePaths([]string{filepath.Join(dir, "*", "*.txt")})
+	r.Error(err)
+}
+
+func Test_runFormatter_Jobs(t *testing.T) {
+	r := require.New(t)
+	pwd, err := os.Getwd()
+	r.NoError(err)
+	dir := t.TempDir()
+	r.NoError(os.Chdir(dir))
+	defer os.Chdir(pwd)
+
+	info := &bytes.Buffer{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("src/file%d.js", i)
+		r.NoError(os.MkdirAll(filepath.Join(dir, "src"), 0755))
+		r.NoError(os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x\n", i+1)), 0644))
+		fmt.Fprintf(info, "SF:%s\nDA:1,%d\nend_of_record\n", filepath.Join(dir, name), i%2)
+	}
+	r.NoError(os.WriteFile(filepath.Join(dir, "lcov.info"), info.Bytes(), 0644))
+
+	outputs := []string{}
+	for _, jobs := range []int{0, 1, 8} {
+		bb := &bytes.Buffer{}
+		envy.Temp(func() {
+			// the temp dir isn't a git repo
+			envy.Set("GIT_BRANCH", "master")
+			envy.Set("GIT_COMMIT_SHA", "a12345")
+			envy.Set("GIT_COMMITTED_AT", "1234")
+			err := runFormatter(CoverageFormatter{
+				CoveragePaths: []string{filepath.Join(dir, "lcov.info")},
+				InputType:     "lcov",
+				Prefix:        dir,
+				Jobs:          jobs,
+				writer:        bb,
+			})
+			r.NoError(err)
+		})
+		outputs = append(outputs, bb.String())
+	}
+	r.Equal(outputs[0], outputs[1])
+	r.Equal(outputs[0], outputs[2])
+}
+
+func Test_runFormatter_Exclude(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	dir := t.TempDir()
+	info := "SF:src/index.js\nDA:1,1\nDA:2,0\nend_of_record\nSF:vendor/lib.js\nDA:1,0\nend_of_record\nSF:src/index.test.js\nDA:1,0\nend_of_record\n"
+	r.NoError(os.WriteFile(filepath.Join(dir, "lcov.info"), []byte(info), 0644))
+
+	bb := &bytes.Buffer{}
+	envy.Temp(func() {
+		err := runFormatter(CoverageFormatter{
+			CoveragePaths: []string{filepath.Join(dir, "lcov.info")},
+			InputType:     "lcov",
+			Exclude:       []string{"vendor/**", "**/*.test.js"},
+			writer:        bb,
+		})
+		r.NoError(err)
+	})
+
+	rep := formatters.Report{SourceFiles: formatters.SourceFiles{}}
+	r.NoError(json.Unmarshal(bb.Bytes(), &rep))
+	r.Len(rep.SourceFiles, 1)
+	r.Equal(2, rep.LineCounts.Total)
+	r.InDelta(50, rep.CoveredPercent, 0.01)
+	r.Equal(map[string][]string{
+		"vendor/**":    {"vendor/lib.js"},
+		"**/*.test.js": {"src/index.test.js"},
+	}, rep.ExcludedFiles)
+}
+
+func Test_runFormatter_GoCoverDirs(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	bb := &bytes.Buffer{}
+	envy.Temp(func() {
+		err := runFormatter(CoverageFormatter{
+			CoveragePaths: []string{"../formatters/gocovdata/example/run1", "../formatters/gocovdata/example/run2"},
+			InputType:     "gocovdata",
+			writer:        bb,
+		})
+		r.NoError(err)
+	})
+
+	// set mode counters are merged, rather than added up per line
+	rep := formatters.Report{SourceFiles: formatters.SourceFiles{}}
+	r.NoError(json.Unmarshal(bb.Bytes(), &rep))
+	r.Len(rep.SourceFiles, 2)
+	r.Equal(1, rep.SourceFiles["formatters/gocovdata/example/calc/calc.go"].Coverage[4].Int)
+}
+
+func Test_runFormatter_GoCoverDirs_InputRoot(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	example := filepath.Join("..", "formatters", "gocovdata", "example")
+	bb := &bytes.Buffer{}
+	envy.Temp(func() {
+		err := runFormatter(CoverageFormatter{
+			CoveragePaths: []string{filepath.Join(example, "run1"), filepath.Join(example, "run2")},
+			InputType:     "gocovdata",
+			InputRoot:     filepath.Join("..", "..", ".."),
+			Prefix:        "..",
+			writer:        bb,
+		})
+		r.NoError(err)
+	})
+
+	// each directory is formatted on its own, and the set mode counters
+	// are still merged rather than added up
+	rep := formatters.Report{SourceFiles: formatters.SourceFiles{}}
+	r.NoError(json.Unmarshal(bb.Bytes(), &rep))
+	r.Len(rep.SourceFiles, 2)
+	r.Equal(1, rep.SourceFiles["formatters/gocovdata/example/calc/calc.go"].Coverage[4].Int)
+
+	envy.Temp(func() {
+		err := runFormatter(CoverageFormatter{
+			CoveragePaths: []string{filepath.Join(example, "run1"), filepath.Join(example, "count")},
+			InputType:     "gocovdata",
+			InputRoot:     filepath.Join("..", "..", ".."),
+			writer:        &bytes.Buffer{},
+		})
+		r.Error(err)
+		r.Contains(err.Error(), "can't merge count coverage")
+	})
+}
@@ -22,10 +22,11 @@ var RootCmd = &cobra.Command{
 	Short: "Report information about tests to Code Climate",
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if debug {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
+		return applyConfig(cmd)
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		v, err := cmd.Flags().GetBool("version")
@@ -2,6 +2,7 @@ package cmd
 
 import (
   "fmt"
+  "sort"
   "strings"
   "io/ioutil"
   "encoding/json"
@@ -15,9 +16,93 @@ func getLineCount(result map[string]interface{}, key string) int {
   return int(line_counts[key].(float64))
 }
 
+func getBranchCount(result map[string]interface{}, key string) int {
+  return getOptionalCount(result, "branch_counts", key)
+}
+
+func getFunctionCount(result map[string]interface{}, key string) int {
+  return getOptionalCount(result, "function_counts", key)
+}
+
+// getOptionalCount reads counts that reports written by older versions,
+// or by formatters that don't collect them, leave out.
+func getOptionalCount(result map[string]interface{}, counts string, key string) int {
+  values, ok := result[counts].(map[string]interface{})
+  if !ok {
+    return 0
+  }
+  count, _ := values[key].(float64)
+  return int(count)
+}
+
 func printHeader(result map[string]interface{}) {
   header := "Coverage: %.2f%% (%d/%d lines covered, %d missing)"
   fmt.Println(fmt.Sprintf(header, result["covered_percent"], getLineCount(result, "covered"), getLineCount(result, "total"), getLineCount(result, "missed")))
+
+  // reports from formatters without branch data have nothing more to say
+  if getBranchCount(result, "total") > 0 {
+    header = "Branch coverage: %.2f%% (%d/%d branches covered, %d missing)"
+    fmt.Println(fmt.Sprintf(header, result["branch_covered_percent"], getBranchCount(result, "covered"), getBranchCount(result, "total"), getBranchCount(result, "missed")))
+  }
+
+  if getFunctionCount(result, "total") > 0 {
+    header = "Functions: %d/%d functions covered, %d missing"
+    fmt.Println(fmt.Sprintf(header, getFunctionCount(result, "covered"), getFunctionCount(result, "total"), getFunctionCount(result, "missed")))
+  }
+}
+
+func printUncoveredFunctions(result map[string]interface{}) {
+  if getFunctionCount(result, "missed") > 0 {
+
+    fmt.Println("Uncovered functions by file:")
+    files := result["source_files"].([]interface{})
+
+    for _, file_obj := range files {
+      file := file_obj.(map[string]interface{})
+      printUncoveredFunctionsFromFile(file)
+    }
+  }
+}
+
+func printUncoveredFunctionsFromFile(file map[string]interface{}) {
+  functions, ok := file["functions"].([]interface{})
+  if !ok {
+    return
+  }
+
+  var uncovered_functions []string
+  for _, function_obj := range functions {
+    function := function_obj.(map[string]interface{})
+    if function["hits"].(float64) == 0 {
+      uncovered_functions = append(uncovered_functions, fmt.Sprintf("%s (line %d)", function["name"], int(function["start_line"].(float64))))
+    }
+  }
+  if len(uncovered_functions) > 0 {
+    fmt.Println(fmt.Sprintf("%s: %s", file["name"], strings.Join(uncovered_functions, ", ")))
+  }
+}
+
+func printExcludedFiles(result map[string]interface{}) {
+  excluded, _ := result["excluded_files"].(map[string]interface{})
+  not_included, _ := result["not_included_files"].([]interface{})
+  if len(excluded) == 0 && len(not_included) == 0 {
+    return
+  }
+
+  fmt.Println("Files left out of the report:")
+  if len(not_included) > 0 {
+    fmt.Println(fmt.Sprintf("not matching any include pattern: %d files", len(not_included)))
+  }
+
+  var patterns []string
+  for pattern := range excluded {
+    patterns = append(patterns, pattern)
+  }
+  sort.Strings(patterns)
+  for _, pattern := range patterns {
+    files, _ := excluded[pattern].([]interface{})
+    fmt.Println(fmt.Sprintf("%s: %d files", pattern, len(files)))
+  }
 }
 
 func printUncoveredLines(result map[string]interface{}) {
@@ -67,9 +152,15 @@ var showCoverageCmd = &cobra.Command{
 
     printHeader(result)
 
+    // How many files --include and --exclude removed
+    printExcludedFiles(result)
+
     // If there are missed lines, print which are them, by file
     printUncoveredLines(result)
 
+    // Same for functions that were never called
+    printUncoveredFunctions(result)
+
     return nil
   },
 }
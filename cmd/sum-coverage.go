@@ -10,8 +10,10 @@ import (
 )
 
 type CoverageSummer struct {
-	Output string
-	Parts  int
+	Output  string
+	Parts   int
+	Include []string
+	Exclude []string
 }
 
 var summerOptions = CoverageSummer{}
@@ -27,6 +29,11 @@ var sumCoverageCmd = &cobra.Command{
 			return errors.Errorf("expected %d parts, received %d parts", summerOptions.Parts, len(args))
 		}
 
+		filter, err := formatters.NewFileFilter(summerOptions.Include, summerOptions.Exclude)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
 		rep := formatters.Report{
 			SourceFiles: formatters.SourceFiles{},
 		}
@@ -61,6 +68,11 @@ var sumCoverageCmd = &cobra.Command{
 			}
 		}
 
+		err = rep.Filter(filter)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
 		out, err := writer(summerOptions.Output)
 		if err != nil {
 			return errors.WithStack(err)
@@ -76,6 +88,8 @@ var sumCoverageCmd = &cobra.Command{
 
 func init() {
 	sumCoverageCmd.Flags().IntVarP(&summerOptions.Parts, "parts", "p", 0, "total number of parts to sum")
+	sumCoverageCmd.Flags().StringArrayVar(&summerOptions.Include, "include", []string{}, "only report files matching this glob, e.g. 'src/**'. can be given several times")
+	sumCoverageCmd.Flags().StringArrayVar(&summerOptions.Exclude, "exclude", []string{}, "leave out files matching this glob, e.g. 'vendor/**'. can be given several times")
 	sumCoverageCmd.Flags().StringVarP(&summerOptions.Output, "output", "o", ccDefaultCoveragePath, "output path")
 	RootCmd.AddCommand(sumCoverageCmd)
 }
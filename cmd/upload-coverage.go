@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 
 	"github.com/codeclimate/test-reporter/upload"
@@ -10,6 +11,7 @@ import (
 )
 
 var uploadInput string
+var skipValidation bool
 var uploadOptions = upload.Uploader{}
 
 // uploadCoverageCmd represents the upload command
@@ -17,15 +19,19 @@ var uploadCoverageCmd = &cobra.Command{
 	Use:   "upload-coverage",
 	Short: "Upload pre-formatted coverage payloads to Code Climate servers.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		if uploadInput == "-" {
-			uploadOptions.Input = os.Stdin
-		} else {
-			uploadOptions.Input, err = os.Open(uploadInput)
+		data, err := readInput(uploadInput)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if !skipValidation {
+			err = validateReport(data, uploadInput, os.Stderr)
 			if err != nil {
-				return errors.WithStack(err)
+				return errors.Wrap(err, "not uploading an invalid coverage payload, see validate-coverage")
 			}
 		}
+
+		uploadOptions.Input = bytes.NewReader(data)
 		return uploadOptions.Upload()
 	},
 }
@@ -36,6 +42,7 @@ func init() {
 	uploadCoverageCmd.Flags().StringVarP(&uploadOptions.EndpointURL, "endpoint", "e", envy.Get("CC_TEST_REPORTER_COVERAGE_ENDPOINT", "https://api.codeclimate.com/v1/test_reports"), "endpoint to upload coverage information to")
 	uploadCoverageCmd.Flags().IntVarP(&uploadOptions.BatchSize, "batch-size", "s", 500, "batch size for source files")
 	uploadCoverageCmd.Flags().BoolVar(&uploadOptions.Insecure, "insecure", false, "send coverage insecurely (without HTTPS)")
+	uploadCoverageCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "upload the coverage payload without checking it first")
 
 	RootCmd.AddCommand(uploadCoverageCmd)
 }
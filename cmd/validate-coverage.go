@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/codeclimate/test-reporter/validate"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// ReportSchema is the contents of schema.json, set by main.
+var ReportSchema []byte
+
+var validateCoverageCmd = &cobra.Command{
+	Use:          "validate-coverage [coverage file]",
+	Short:        "Check a pre-formatted coverage payload is valid before uploading it.",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input := ccDefaultCoveragePath
+		if len(args) != 0 {
+			input = args[0]
+		}
+
+		data, err := readInput(input)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return validateReport(data, input, os.Stdout)
+	},
+}
+
+// validateReport prints the problems found in a coverage payload to w,
+// and fails if there are any.
+func validateReport(data []byte, name string, w io.Writer) error {
+	schema, err := validate.ParseSchema(ReportSchema)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	problems := validate.Report(data, schema)
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("found %d problems in %s", len(problems), name)
+	}
+	return nil
+}
+
+func readInput(p string) ([]byte, error) {
+	if p == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(p)
+}
+
+func init() {
+	RootCmd.AddCommand(validateCoverageCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateReport(t *testing.T) {
+	r := require.New(t)
+
+	schema, err := os.ReadFile("../schema.json")
+	r.NoError(err)
+	defer func() { ReportSchema = nil }()
+	ReportSchema = schema
+
+	data, err := os.ReadFile("../integration-tests/codeclimate.json")
+	r.NoError(err)
+	out := &bytes.Buffer{}
+	r.NoError(validateReport(data, "codeclimate.json", out))
+	r.Empty(out.String())
+
+	err = validateReport([]byte(`{"source_files": []}`), "empty.json", out)
+	r.Error(err)
+	r.Contains(err.Error(), "problems in empty.json")
+	r.Contains(out.String(), "$.git: is required\n")
+	r.Contains(out.String(), "$.source_files: has 0 items, expected at least 1\n")
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// blobIndexes caches the path to blob id index of each commit tree that
+// was looked up, so the tree is walked only once however many files are
+// looked up in it.
+var blobIndexes = struct {
+	sync.Mutex
+	m map[plumbing.Hash]map[string]string
+}{m: map[plumbing.Hash]map[string]string{}}
+
+// BlobIndex returns the blob ids of all the files in the commit's tree,
+// keyed by their path relative to the repo root.
+func BlobIndex(commit *object.Commit) (map[string]string, error) {
+	blobIndexes.Lock()
+	defer blobIndexes.Unlock()
+
+	if index, ok := blobIndexes.m[commit.Hash]; ok {
+		return index, nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	logrus.Debugf("indexing git blob_ids of commit %s", commit.Hash)
+	index := map[string]string{}
+	walker := object.NewTreeWalker(tree, true)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// directories and submodules have no blob of their own
+		if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+			continue
+		}
+		index[name] = entry.Hash.String()
+	}
+
+	blobIndexes.m[commit.Hash] = index
+	return index, nil
+}
+
+// CheckBlob returns the error GitBlob would return for path, without
+// hashing the file, so the blob ids of many files can be computed later
+// with GitBlobs.
+func CheckBlob(path string, commit *object.Commit) error {
+	if commit != nil {
+		if index, err := BlobIndex(commit); err == nil {
+			if _, ok := index[path]; ok {
+				return nil
+			}
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		logrus.Errorf("failed to read file %s\n%s", path, err)
+		return errors.WithStack(err)
+	}
+	if fi.IsDir() {
+		err = errors.Errorf("%s is a directory", path)
+		logrus.Errorf("failed to read file %s\n%s", path, err)
+		return err
+	}
+	return nil
+}
+
+// GitBlobs calls GitBlob for each of the paths using at most jobs workers
+// at a time, and returns the blob ids keyed by path. If several paths
+// fail, the error of the first one in paths is returned.
+func GitBlobs(paths []string, commit *object.Commit, jobs int) (map[string]string, error) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	blobs := make([]string, len(paths))
+	errs := make([]error, len(paths))
+
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				blobs[i], errs[i] = GitBlob(paths[i], commit)
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	res := make(map[string]string, len(paths))
+	for i, p := range paths {
+		if errs[i] != nil {
+			return res, errors.WithStack(errs[i])
+		}
+		res[p] = blobs[i]
+	}
+	return res, nil
+}
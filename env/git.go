@@ -112,12 +112,12 @@ func GitSHA(path string) (string, error) {
 
 var GitBlob = func(path string, commit *object.Commit) (string, error) {
 	if commit != nil {
-		file, err := commit.File(path)
+		index, err := BlobIndex(commit)
 		if err == nil {
-			logrus.Debugf("getting git blob_id for source file %s", path)
-
-			blob := strings.TrimSpace(file.Hash.String())
-			return blob, nil
+			if blob, ok := index[path]; ok {
+				logrus.Debugf("getting git blob_id for source file %s", path)
+				return blob, nil
+			}
 		}
 	}
 
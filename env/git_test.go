@@ -1,10 +1,15 @@
 package env
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gobuffalo/envy"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
 )
 
 func Test_FindGitInfo(t *testing.T) {
@@ -78,3 +83,54 @@ func Test_loadGitFromENV_CI_TIMESTAMP(t *testing.T) {
 		r.Equal(g.CommittedAt, 1345)
 	})
 }
+
+func Test_BlobIndex(t *testing.T) {
+	r := require.New(t)
+	repo, err := git.PlainOpen("..")
+	r.NoError(err)
+	ref, err := repo.Head()
+	r.NoError(err)
+	commit, err := repo.CommitObject(ref.Hash())
+	r.NoError(err)
+
+	index, err := BlobIndex(commit)
+	r.NoError(err)
+
+	file, err := commit.File("env/git.go")
+	r.NoError(err)
+	r.Equal(file.Hash.String(), index["env/git.go"])
+	r.NotContains(index, "env")
+
+	blob, err := GitBlob("env/git.go", commit)
+	r.NoError(err)
+	r.Equal(file.Hash.String(), blob)
+}
+
+func Test_GitBlobs(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	paths := []string{}
+	for i := 0; i < 20; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		r.NoError(os.WriteFile(p, []byte(strings.Repeat("x", i)), 0644))
+		paths = append(paths, p)
+	}
+
+	for _, jobs := range []int{0, 1, 4} {
+		blobs, err := GitBlobs(paths, nil, jobs)
+		r.NoError(err)
+		r.Len(blobs, len(paths))
+		for _, p := range paths {
+			blob, err := fallbackBlob(p)
+			r.NoError(err)
+			r.Equal(blob, blobs[p])
+		}
+	}
+
+	_, err := GitBlobs(append(paths, filepath.Join(dir, "missing.txt")), nil, 4)
+	r.Error(err)
+	r.Error(CheckBlob(filepath.Join(dir, "missing.txt"), nil))
+	r.Error(CheckBlob(dir, nil))
+	r.NoError(CheckBlob(paths[0], nil))
+}
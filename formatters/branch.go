@@ -0,0 +1,55 @@
+package formatters
+
+import "sort"
+
+// Branch is a single outcome of a conditional in a source file.
+// ID identifies the branch among the others starting on the same
+// line, using whatever naming the coverage tool provides.
+type Branch struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id"`
+	Taken int    `json:"taken"`
+}
+
+type Branches []Branch
+
+// Merge sums the taken counts of branches found in both a and b and
+// keeps the branches only one of them knows about.
+func (a Branches) Merge(b Branches) Branches {
+	if len(b) == 0 {
+		return a
+	}
+
+	merged := make(Branches, 0, len(a)+len(b))
+	index := map[Branch]int{}
+	for _, br := range append(append(Branches{}, a...), b...) {
+		key := Branch{Line: br.Line, ID: br.ID}
+		if i, ok := index[key]; ok {
+			merged[i].Taken += br.Taken
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, br)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Line < merged[j].Line
+	})
+	return merged
+}
+
+// Counts tallies the branches the same way lines are tallied: a branch
+// that was taken at least once is covered.
+func (bs Branches) Counts() LineCounts {
+	lc := LineCounts{}
+	for _, br := range bs {
+		lc.Total++
+		lc.Strength += br.Taken
+		if br.Taken == 0 {
+			lc.Missed++
+			continue
+		}
+		lc.Covered++
+	}
+	return lc
+}
@@ -14,7 +14,8 @@ import (
 var searchPaths = []string{"build/logs/clover.xml", "clover.xml"}
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -41,45 +42,96 @@ func (r Formatter) Format() (formatters.Report, error) {
 		return rep, errors.WithStack(err)
 	}
 
-	c := &xmlClover{}
-	err = xml.NewDecoder(fx).Decode(c)
-	if err != nil {
-		return rep, errors.WithStack(err)
-	}
+	defer fx.Close()
 
 	gitHead, _ := env.GetHead()
 
-	files := c.Files
-	for _, pp := range c.Packages {
-		files = append(files, pp.Files...)
-	}
+	// <file> elements sit either directly under <project> or under one of
+	// its <package>s; either way they are decoded one at a time. OpenClover
+	// puts the test sources under a <testproject>, which is left out.
+	inProject := false
+	err = formatters.StreamXML(fx, "coverage", func(d *xml.Decoder, t xml.Token) error {
+		if ee, ok := t.(xml.EndElement); ok && ee.Name.Local == "project" {
+			inProject = false
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+		if se.Name.Local == "project" {
+			inProject = true
+		}
+		if !inProject || se.Name.Local != "file" {
+			return nil
+		}
 
-	for _, pf := range files {
-		num := 1
+		pf := xmlFile{}
+		if err := d.DecodeElement(&pf, &se); err != nil {
+			return errors.WithStack(err)
+		}
 
 		path := pf.Path
 		if len(path) == 0 {
 			path = pf.Name
 		}
 
-		sf, err := formatters.NewSourceFile(path, gitHead)
+		sf, err := formatters.NewSourceFile(path, gitHead, r.Options)
 		if err != nil {
-			return rep, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
+		counted := xmlMetrics{}
 		for _, l := range pf.Lines {
-			for num < l.Num {
+			if l.Num < 1 {
+				continue
+			}
+			count := l.Count
+			switch l.Type {
+			case "method":
+				// the line a method is declared on isn't a statement
+				name := l.Name
+				if name == "" {
+					// OpenClover only gives the signature
+					name = l.Signature
+				}
+				sf.Functions = append(sf.Functions, formatters.Function{Name: name, StartLine: l.Num, Hits: l.Count})
+				continue
+			case "cond":
+				// the line ran whenever the condition was evaluated, one
+				// side or the other; the branches tell whether both were
+				if count == 0 {
+					count = l.TrueCount + l.FalseCount
+				}
+				sf.Branches = append(sf.Branches,
+					formatters.Branch{Line: l.Num, ID: "true", Taken: l.TrueCount},
+					formatters.Branch{Line: l.Num, ID: "false", Taken: l.FalseCount},
+				)
+				counted.Conditionals += 2
+				for _, c := range []int{l.TrueCount, l.FalseCount} {
+					if c > 0 {
+						counted.CoveredConditionals++
+					}
+				}
+			default:
+				counted.Statements++
+				if count > 0 {
+					counted.CoveredStatements++
+				}
+			}
+
+			for len(sf.Coverage) < l.Num {
 				sf.Coverage = append(sf.Coverage, formatters.NullInt{})
-				num++
 			}
-			ni := formatters.NewNullInt(l.Count)
-			sf.Coverage = append(sf.Coverage, ni)
-			num++
+			// a line with several statements is only covered if all of
+			// them ran
+			if prev := sf.Coverage[l.Num-1]; !prev.Valid || count < prev.Int {
+				sf.Coverage[l.Num-1] = formatters.NewNullInt(count)
+			}
 		}
-		err = rep.AddSourceFile(sf)
-		if err != nil {
-			return rep, errors.WithStack(err)
+		if pf.Metrics != nil && *pf.Metrics != counted {
+			logrus.Warnf("the lines of %s don't add up to its metrics in %s: found %+v, expected %+v", path, r.Path, counted, *pf.Metrics)
 		}
-	}
+		return errors.WithStack(rep.AddSourceFile(sf))
+	})
 
-	return rep, nil
+	return rep, err
 }
@@ -1,11 +1,16 @@
 package clover
 
 import (
+	"bytes"
+	"os"
+	"strings"
 	"testing"
 
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,6 +35,8 @@ func Test_Parse(t *testing.T) {
 	r.True(sf.Coverage[54].Valid)
 	r.Equal(4, sf.Coverage[53].Int)
 	r.Equal(0, sf.Coverage[55].Int)
+	r.Equal(61, sf.LineCounts.Total)
+	r.Len(sf.Functions, 12)
 }
 
 func Test_Parse_Without_Package(t *testing.T) {
@@ -54,3 +61,84 @@ func Test_Parse_Without_Package(t *testing.T) {
 	r.Equal(0, sf.Coverage[38].Int)
 	r.Equal(5, sf.Coverage[62].Int)
 }
+
+func Test_Parse_TestProject(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./testproject_example.xml"}
+	rep, err := f.Format()
+	r.NoError(err)
+	// the test sources under <testproject> are left out
+	r.Len(rep.SourceFiles, 1)
+	r.Contains(rep.SourceFiles, "src/main/java/com/example/Calc.java")
+	r.Equal(3, rep.LineCounts.Total)
+	r.Equal(2, rep.LineCounts.Covered)
+	// OpenClover names methods by their signatures
+	r.Equal(formatters.Functions{{Name: "add(int, int) : int", StartLine: 4, Hits: 2}}, rep.SourceFiles["src/main/java/com/example/Calc.java"].Functions)
+}
+
+func Test_Parse_Line_Types(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./cond_example.xml"}
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 1)
+
+	sf := rep.SourceFiles["src/main/java/com/example/Calc.java"]
+	r.Len(sf.Coverage, 8)
+	// method lines aren't statements
+	r.False(sf.Coverage[2].Valid)
+	r.Equal(formatters.Functions{{Name: "clamp", StartLine: 3, Hits: 4}}, sf.Functions)
+	// a condition only one side of ran is a covered line and a missed
+	// branch, as in PHPUnit's HTML report: 4 of 5 lines, 3 of 4 branches
+	r.Equal(4, sf.Coverage[3].Int)
+	r.Equal(3, sf.Coverage[5].Int)
+	r.Equal(5, sf.LineCounts.Total)
+	r.Equal(4, sf.LineCounts.Covered)
+	r.Equal(formatters.Branches{
+		{Line: 4, ID: "true", Taken: 1},
+		{Line: 4, ID: "false", Taken: 3},
+		{Line: 6, ID: "true", Taken: 0},
+		{Line: 6, ID: "false", Taken: 3},
+	}, sf.Branches)
+	r.Equal(3, sf.BranchCounts.Covered)
+	r.Equal(1, sf.BranchCounts.Missed)
+}
+
+func Test_Parse_Metrics_Mismatch(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	out := &bytes.Buffer{}
+	logrus.SetOutput(out)
+	defer logrus.SetOutput(os.Stderr)
+
+	f := &Formatter{Path: "./cond_example.xml"}
+	_, err := f.Format()
+	r.NoError(err)
+	r.Empty(out.String())
+
+	// the lines of two of its files don't add up to their metrics
+	f = &Formatter{Path: "./example_without_package.xml"}
+	_, err = f.Format()
+	r.NoError(err)
+	r.Equal(2, strings.Count(out.String(), "don't add up to its metrics"))
+}
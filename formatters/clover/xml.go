@@ -1,21 +1,30 @@
 package clover
 
-import "encoding/xml"
-
 type xmlFile struct {
-	Name  string `xml:"name,attr"`
-	Path  string `xml:"path,attr"`
-	Lines []struct {
-		Num   int `xml:"num,attr"`
-		Count int `xml:"count,attr"`
-	} `xml:"line"`
+	Name    string      `xml:"name,attr"`
+	Path    string      `xml:"path,attr"`
+	Lines   []xmlLine   `xml:"line"`
+	Metrics *xmlMetrics `xml:"metrics"`
+}
+
+// xmlLine is a statement, a method, or a condition, as told by its type.
+// Conditions also count how often they were true and how often false.
+type xmlLine struct {
+	Num        int    `xml:"num,attr"`
+	Type       string `xml:"type,attr"`
+	Name       string `xml:"name,attr"`
+	Signature  string `xml:"signature,attr"`
+	Count      int    `xml:"count,attr"`
+	TrueCount  int    `xml:"truecount,attr"`
+	FalseCount int    `xml:"falsecount,attr"`
 }
 
-type xmlClover struct {
-	XMLName  xml.Name `xml:"coverage"`
-	Packages []struct {
-		Name  string    `xml:"name,attr"`
-		Files []xmlFile `xml:"file"`
-	} `xml:"project>package"`
-	Files []xmlFile `xml:"project>file"`
+// xmlMetrics sums up the lines of a file. Each condition counts as two
+// conditionals, one for each side. Methods are left out, as PHPUnit only
+// counts those with all their statements covered.
+type xmlMetrics struct {
+	Statements          int `xml:"statements,attr"`
+	CoveredStatements   int `xml:"coveredstatements,attr"`
+	Conditionals        int `xml:"conditionals,attr"`
+	CoveredConditionals int `xml:"coveredconditionals,attr"`
 }
@@ -10,12 +10,14 @@ import (
 	"github.com/codeclimate/test-reporter/env"
 	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 var searchPaths = []string{"cobertura.xml"}
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -42,61 +44,97 @@ func (r Formatter) Format() (formatters.Report, error) {
 		return rep, errors.WithStack(err)
 	}
 
-	coberturaFile := &xmlFile{}
-	err = xml.NewDecoder(fx).Decode(coberturaFile)
-
-	if err != nil {
-		return rep, errors.WithStack(err)
-	}
+	defer fx.Close()
 
 	gitHead, _ := env.GetHead()
-	for _, pp := range coberturaFile.Packages {
-		mergedClasses := make(map[string]*xmlClass)
-		// merge Classes by filename
-		for i, clss := range pp.Classes {
-			filename := clss.FileName
-			if _, ok := mergedClasses[filename]; ok {
-				// Appends lines for mergedClasses with the same filename
-				lines := append(mergedClasses[filename].Lines, clss.Lines...)
-				mergedClasses[filename].Lines = lines
-			} else {
-				mergedClasses[filename] = &pp.Classes[i]
-			}
-		}
 
-		for _, pf := range mergedClasses {
-			num := 1
-			fileName := coberturaFile.getFullFilePath(pf.FileName)
-			logrus.Debugf("creating test file report for %s", fileName)
-			sf, err := formatters.NewSourceFile(fileName, gitHead)
-			if err != nil {
-				return rep, errors.WithStack(err)
-			}
-			sort.Sort(ByLineNum(pf.Lines))
-			for _, l := range pf.Lines {
-				if l.Num > 0 {
-					for num < l.Num {
-						sf.Coverage = append(sf.Coverage, formatters.NullInt{})
-						num++
-					}
-					if l.Num <= len(sf.Coverage) {
-						hits := sf.Coverage[l.Num-1].Int + l.Hits
-						sf.Coverage[l.Num-1] = formatters.NewNullInt(hits)
-					} else {
-						ni := formatters.NewNullInt(l.Hits)
-						sf.Coverage = append(sf.Coverage, ni)
-						num++
-					}
+	// <sources> come before <packages>, and the classes of a package are
+	// merged by filename until the package ends, so only one package is
+	// held in memory at a time
+	coberturaFile := xmlFile{}
+	mergedClasses := map[string]*xmlClass{}
+	fileNames := []string{}
+
+	err = formatters.StreamXML(fx, "coverage", func(d *xml.Decoder, t xml.Token) error {
+		switch el := t.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "source":
+				source := Source{}
+				if err := d.DecodeElement(&source, &el); err != nil {
+					return errors.WithStack(err)
+				}
+				coberturaFile.Sources = append(coberturaFile.Sources, source)
+			case "package":
+				mergedClasses = map[string]*xmlClass{}
+				fileNames = []string{}
+			case "class":
+				clss := &xmlClass{}
+				if err := d.DecodeElement(clss, &el); err != nil {
+					return errors.WithStack(err)
+				}
+				filename := clss.FileName
+				if _, ok := mergedClasses[filename]; ok {
+					// Appends lines for mergedClasses with the same filename
+					lines := append(mergedClasses[filename].Lines, clss.Lines...)
+					mergedClasses[filename].Lines = lines
 				} else {
-					logrus.Warnf("Invalid line number %d in file %s", l.Num, fileName)
+					mergedClasses[filename] = clss
+					fileNames = append(fileNames, filename)
+				}
+			}
+		case xml.EndElement:
+			if el.Name.Local != "package" {
+				return nil
+			}
+			for _, filename := range fileNames {
+				sf, err := coberturaFile.sourceFile(mergedClasses[filename], gitHead, r.Options)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				if err := rep.AddSourceFile(sf); err != nil {
+					return errors.WithStack(err)
 				}
 			}
-			err = rep.AddSourceFile(sf)
-			if err != nil {
-				return rep, errors.WithStack(err)
+		}
+		return nil
+	})
+
+	return rep, err
+}
+
+// sourceFile builds the coverage of a file from the lines of all of its
+// classes.
+func (coberturaFile xmlFile) sourceFile(pf *xmlClass, gitHead *object.Commit, opts formatters.SourceFileOptions) (formatters.SourceFile, error) {
+	num := 1
+	fileName := coberturaFile.getFullFilePath(pf.FileName)
+	logrus.Debugf("creating test file report for %s", fileName)
+	sf, err := formatters.NewSourceFile(fileName, gitHead, opts)
+	if err != nil {
+		return sf, errors.WithStack(err)
+	}
+	sort.Sort(ByLineNum(pf.Lines))
+	branches := formatters.Branches{}
+	for _, l := range pf.Lines {
+		if l.Num > 0 {
+			branches = append(branches, l.branches()...)
+			for num < l.Num {
+				sf.Coverage = append(sf.Coverage, formatters.NullInt{})
+				num++
+			}
+			if l.Num <= len(sf.Coverage) {
+				hits := sf.Coverage[l.Num-1].Int + l.Hits
+				sf.Coverage[l.Num-1] = formatters.NewNullInt(hits)
+			} else {
+				ni := formatters.NewNullInt(l.Hits)
+				sf.Coverage = append(sf.Coverage, ni)
+				num++
 			}
+		} else {
+			logrus.Warnf("Invalid line number %d in file %s", l.Num, fileName)
 		}
 	}
-
-	return rep, nil
+	// the same line can show up in several merged classes
+	sf.Branches = formatters.Branches{}.Merge(branches)
+	return sf, nil
 }
@@ -6,6 +6,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +34,9 @@ func Test_Parse(t *testing.T) {
 	r.Equal(21, sf.Coverage[19].Int)
 	r.Equal(15, sf.Coverage[20].Int)
 
+	r.Contains(sf.Branches, formatters.Branch{Line: 23, ID: "0", Taken: 1})
+	r.Contains(sf.Branches, formatters.Branch{Line: 23, ID: "1", Taken: 0})
+
 	sf = rep.SourceFiles["search/LinearSearch.java"]
 	r.Equal(2, sf.Coverage[9].Int)
 	r.Equal(3, sf.Coverage[23].Int)
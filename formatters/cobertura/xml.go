@@ -1,14 +1,40 @@
 package cobertura
 
 import (
-	"encoding/xml"
 	"fmt"
 	"os"
+	"strconv"
+
+	"github.com/codeclimate/test-reporter/formatters"
 )
 
 type Lines struct {
-	Num  int `xml:"number,attr"`
-	Hits int `xml:"hits,attr"`
+	Num               int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr"`
+}
+
+// branches expands the "50% (1/2)" condition-coverage attribute into
+// individual branches. Cobertura doesn't say how often each branch was
+// taken, so covered branches are recorded as taken once.
+func (l Lines) branches() formatters.Branches {
+	branches := formatters.Branches{}
+	if !l.Branch {
+		return branches
+	}
+	var percent, covered, total int
+	if _, err := fmt.Sscanf(l.ConditionCoverage, "%d%% (%d/%d)", &percent, &covered, &total); err != nil {
+		return branches
+	}
+	for i := 0; i < total; i++ {
+		taken := 0
+		if i < covered {
+			taken = 1
+		}
+		branches = append(branches, formatters.Branch{Line: l.Num, ID: strconv.Itoa(i), Taken: taken})
+	}
+	return branches
 }
 
 type Source struct {
@@ -22,12 +48,7 @@ type xmlClass struct {
 }
 
 type xmlFile struct {
-	XMLName  xml.Name `xml:"coverage"`
-	Sources  []Source `xml:"sources>source"`
-	Packages []struct {
-		Name    string     `xml:"name,attr"`
-		Classes []xmlClass `xml:"classes>class"`
-	} `xml:"packages>package"`
+	Sources []Source
 }
 
 // Interface to sort []Lines by line number
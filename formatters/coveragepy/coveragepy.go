@@ -1,6 +1,7 @@
 package coveragepy
 
 import (
+	"bufio"
 	"encoding/xml"
 	"os"
 	"strings"
@@ -11,10 +12,12 @@ import (
 	"github.com/pkg/errors"
 )
 
-var searchPaths = []string{"coverage.xml"}
+var searchPaths = []string{"coverage.xml", "coverage.json"}
 
+// Formatter reads the XML or the JSON report of coverage.py.
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -40,19 +43,30 @@ func (r *Formatter) Format() (formatters.Report, error) {
 	if err != nil {
 		return rep, errors.WithStack(err)
 	}
+	defer fx.Close()
+
+	gitHead, _ := env.GetHead()
+
+	br := bufio.NewReader(fx)
+	if isDataFile(br) {
+		return rep, errors.Errorf("%s is a coverage.py data file, which only lists the lines that ran. run \"coverage json\" or \"coverage xml\" and format the report instead", r.Path)
+	}
+	if isJSON(br) {
+		err = formatJSON(&rep, br, gitHead, r.Options)
+		return rep, err
+	}
 
 	coverageFile := &xmlFile{}
-	err = xml.NewDecoder(fx).Decode(coverageFile)
+	err = xml.NewDecoder(br).Decode(coverageFile)
 	if err != nil {
 		return rep, errors.WithStack(err)
 	}
 
-	gitHead, _ := env.GetHead()
 	for _, xmlPackage := range coverageFile.Packages {
 		for _, xmlClass := range xmlPackage.Classes {
 			fileName := coverageFile.getFullFilePath(xmlClass.FileName)
 			logrus.Debugf("creating test file report for %s", fileName)
-			sourceFile, err := formatters.NewSourceFile(fileName, gitHead)
+			sourceFile, err := formatters.NewSourceFile(fileName, gitHead, r.Options)
 			if err != nil {
 				return rep, errors.WithStack(err)
 			}
@@ -75,3 +89,35 @@ func (r *Formatter) Format() (formatters.Report, error) {
 
 	return rep, nil
 }
+
+// dataFilePrefixes start the data files coverage.py writes to .coverage,
+// the JSON ones of versions before 5.0 and the SQLite ones since.
+var dataFilePrefixes = []string{"!coverage.py:", "SQLite format 3\x00"}
+
+func isDataFile(r *bufio.Reader) bool {
+	for _, p := range dataFilePrefixes {
+		if b, err := r.Peek(len(p)); err == nil && string(b) == p {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSON reports whether the first thing in r, whitespace aside, is the
+// start of a JSON object.
+func isJSON(r *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		b, err := r.Peek(n)
+		if err != nil {
+			return false
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,3 +32,44 @@ func Test_Parse(t *testing.T) {
 	r.Equal(1, sf.Coverage[54].Int)
 	r.Equal(0, sf.Coverage[55].Int)
 }
+
+func Test_Parse_JSON(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./example_report.json"}
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+
+	sf := rep.SourceFiles["app/calc.py"]
+	r.Len(sf.Coverage, 9)
+	r.Equal(formatters.NewNullInt(1), sf.Coverage[0])
+	r.False(sf.Coverage[1].Valid)
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[7])
+	r.Equal(6, sf.LineCounts.Covered)
+	r.Equal(1, sf.LineCounts.Missed)
+	r.Equal(formatters.Branches{
+		{Line: 7, ID: "7->9", Taken: 1},
+		{Line: 7, ID: "7->8", Taken: 0},
+	}, sf.Branches)
+
+	r.Equal(7, rep.LineCounts.Covered)
+	r.Equal(8, rep.LineCounts.Total)
+}
+
+func Test_Parse_DataFile(t *testing.T) {
+	r := require.New(t)
+
+	// the data files only list the lines that ran, so they're turned down
+	// rather than reported as fully covered
+	f := &Formatter{Path: "./example.json"}
+	_, err := f.Format()
+	r.Error(err)
+	r.Contains(err.Error(), "is a coverage.py data file")
+}
@@ -0,0 +1,100 @@
+package coveragepy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// jsonFile is the report written by "coverage json".
+type jsonFile struct {
+	Files map[string]struct {
+		ExecutedLines    []int    `json:"executed_lines"`
+		MissingLines     []int    `json:"missing_lines"`
+		ExcludedLines    []int    `json:"excluded_lines"`
+		ExecutedBranches [][2]int `json:"executed_branches"`
+		MissingBranches  [][2]int `json:"missing_branches"`
+	} `json:"files"`
+}
+
+// formatJSON adds the files of a JSON report to rep. coverage.py doesn't
+// count how many times a line ran, so executed lines get a single hit.
+// Excluded lines are left out even if they ran.
+func formatJSON(rep *formatters.Report, r io.Reader, gitHead *object.Commit, opts formatters.SourceFileOptions) error {
+	report := jsonFile{}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return errors.WithStack(err)
+	}
+	if report.Files == nil {
+		return errors.New("found no files in the coverage.py JSON report")
+	}
+
+	names := []string{}
+	for name := range report.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := report.Files[name]
+		logrus.Debugf("creating test file report for %s", name)
+		sourceFile, err := formatters.NewSourceFile(name, gitHead, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		hits := map[int]int{}
+		for _, l := range f.MissingLines {
+			hits[l] = 0
+		}
+		for _, l := range f.ExecutedLines {
+			hits[l] = 1
+		}
+		for _, l := range f.ExcludedLines {
+			delete(hits, l)
+		}
+		last := 0
+		for l := range hits {
+			if l > last {
+				last = l
+			}
+		}
+		sourceFile.Coverage = make(formatters.Coverage, last)
+		for l, h := range hits {
+			if l > 0 {
+				sourceFile.Coverage[l-1] = formatters.NewNullInt(h)
+			}
+		}
+
+		sourceFile.Branches = append(branches(f.ExecutedBranches, 1), branches(f.MissingBranches, 0)...)
+		sort.SliceStable(sourceFile.Branches, func(i, j int) bool {
+			return sourceFile.Branches[i].Line < sourceFile.Branches[j].Line
+		})
+
+		err = rep.AddSourceFile(sourceFile)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// branches turns arcs from one line to another into branches. An arc to a
+// negative line leaves the code object starting on that line.
+func branches(arcs [][2]int, taken int) formatters.Branches {
+	branches := formatters.Branches{}
+	for _, a := range arcs {
+		branches = append(branches, formatters.Branch{
+			Line:  a[0],
+			ID:    fmt.Sprintf("%d->%d", a[0], a[1]),
+			Taken: taken,
+		})
+	}
+	return branches
+}
@@ -15,7 +15,8 @@ var searchPaths = []string{"dotcover.xml"}
 
 // Formatter is the exported struct to be used on format-coverage.go
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 // Search looks for the dotcover test report file in default paths or provided ones.
@@ -32,59 +33,104 @@ func (f *Formatter) Search(paths ...string) (string, error) {
 	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for dotcover. search paths were: %s", strings.Join(paths, ", ")))
 }
 
-// Format transforms the provided test report into a CC readable report format.
+// Format transforms the provided test report into a CC readable report
+// format. Each statement gives its hits to all the lines it spans, and a
+// line with several statements is only covered if all of them are.
 func (f Formatter) Format() (formatters.Report, error) {
 	rep, err := formatters.NewReport()
 	if err != nil {
 		return rep, err
 	}
 
-	c, err := f.readDotCoverXML()
+	files, statements, err := f.readDotCoverXML()
 	if err != nil {
 		return rep, err
 	}
 
+	hits := map[int]map[int]int{}
+	for _, st := range statements {
+		end := st.EndLine
+		if end < st.Line {
+			end = st.Line
+		}
+		lines, ok := hits[st.FileIndex]
+		if !ok {
+			lines = map[int]int{}
+			hits[st.FileIndex] = lines
+		}
+		h := 0
+		if st.Covered {
+			h = 1
+		}
+		for l := st.Line; l <= end; l++ {
+			if prev, ok := lines[l]; !ok || h < prev {
+				lines[l] = h
+			}
+		}
+	}
+
 	gitHead, _ := env.GetHead()
 
-	for _, file := range c.Files {
-		sf, err := formatters.NewSourceFile(file.Path, gitHead)
+	for _, file := range files {
+		sf, err := formatters.NewSourceFile(file.Path, gitHead, f.Options)
 		if err != nil {
-			err = errors.WithStack(err)
-			break
+			return rep, errors.WithStack(err)
 		}
 
-		for _, statement := range c.Statements {
-			if file.Index == statement.FileIndex {
-				if statement.Covered {
-					sf.Coverage = append(sf.Coverage, formatters.NewNullInt(1))
-				} else {
-					sf.Coverage = append(sf.Coverage, formatters.NewNullInt(0))
-				}
+		last := 0
+		for l := range hits[file.Index] {
+			if l > last {
+				last = l
+			}
+		}
+		sf.Coverage = make(formatters.Coverage, last)
+		for l, h := range hits[file.Index] {
+			if l > 0 {
+				sf.Coverage[l-1] = formatters.NewNullInt(h)
 			}
 		}
 
 		err = rep.AddSourceFile(sf)
-
 		if err != nil {
-			err = errors.WithStack(err)
-			break
+			return rep, errors.WithStack(err)
 		}
 	}
 
-	return rep, err
+	return rep, nil
 }
 
-// readDotCoverXML reads the dotCover XML file and returns its contents.
-func (f Formatter) readDotCoverXML() (*xmlDotCover, error) {
+// readDotCoverXML reads the files and the statements of the dotCover XML
+// file.
+func (f Formatter) readDotCoverXML() ([]xmlFile, []xmlStatement, error) {
+	files := []xmlFile{}
+	statements := []xmlStatement{}
+
 	fx, err := os.Open(f.Path)
 	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	c := &xmlDotCover{}
-	if err = xml.NewDecoder(fx).Decode(c); err != nil {
-		return nil, errors.WithStack(err)
+		return files, statements, errors.WithStack(err)
 	}
+	defer fx.Close()
 
-	return c, nil
+	err = formatters.StreamXML(fx, "Root", func(d *xml.Decoder, t xml.Token) error {
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+		switch se.Name.Local {
+		case "File":
+			file := xmlFile{}
+			if err := d.DecodeElement(&file, &se); err != nil {
+				return errors.WithStack(err)
+			}
+			files = append(files, file)
+		case "Statement":
+			st := xmlStatement{}
+			if err := d.DecodeElement(&st, &se); err != nil {
+				return errors.WithStack(err)
+			}
+			statements = append(statements, st)
+		}
+		return nil
+	})
+	return files, statements, err
 }
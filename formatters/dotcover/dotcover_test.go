@@ -40,3 +40,38 @@ func Test_Parse(t *testing.T) {
 	sf_three := rep.SourceFiles[`C:\Users\fulano\Desktop\unit-testing-using-mstest\PrimeService.Tests\PrimeService_IsPrimeShould.cs`]
 	assert.Equal(100.0, sf_three.CoveredPercent)
 }
+
+func Test_Parse_Nested(t *testing.T) {
+	ogb := env.GitBlob
+	defer func() {
+		env.GitBlob = ogb
+	}()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	assert := require.New(t)
+
+	formatter := Formatter{
+		Path: "./nested_example.xml",
+	}
+	rep, err := formatter.Format()
+	assert.NoError(err)
+
+	sf := rep.SourceFiles["/home/ci/src/Shop/Cart.cs"]
+	assert.Len(sf.Coverage, 25)
+	assert.False(sf.Coverage[8].Valid)
+	assert.Equal(1, sf.Coverage[9].Int)
+	// one of the two statements on line 11 wasn't covered
+	assert.True(sf.Coverage[10].Valid)
+	assert.Equal(0, sf.Coverage[10].Int)
+	// a statement spanning lines 12 to 14
+	assert.Equal(1, sf.Coverage[12].Int)
+	assert.Equal(1, sf.Coverage[13].Int)
+	// statements of the nested type and namespace
+	assert.Equal(1, sf.Coverage[19].Int)
+	assert.True(sf.Coverage[24].Valid)
+	assert.Equal(0, sf.Coverage[24].Int)
+	assert.Equal(8, sf.LineCounts.Total)
+	assert.Equal(6, sf.LineCounts.Covered)
+}
@@ -1,15 +1,17 @@
 package dotcover
 
-import "encoding/xml"
+// xmlFile is a <File> of the <FileIndices>, which statements refer to by
+// index.
+type xmlFile struct {
+	Path  string `xml:"Name,attr"`
+	Index int    `xml:"Index,attr"`
+}
 
-type xmlDotCover struct {
-	XMLName xml.Name `xml:"Root"`
-	Files   []struct {
-		Path  string `xml:"Name,attr"`
-		Index int    `xml:"Index,attr"`
-	} `xml:"FileIndices>File"`
-	Statements []struct {
-		FileIndex int  `xml:"FileIndex,attr"`
-		Covered   bool `xml:"Covered,attr"`
-	} `xml:"Assembly>Namespace>Type>Method>Statement"`
+// xmlStatement is a <Statement>, found in the <Method>s of <Type>s, which
+// can be nested in other types and in namespaces to any depth.
+type xmlStatement struct {
+	FileIndex int  `xml:"FileIndex,attr"`
+	Line      int  `xml:"Line,attr"`
+	EndLine   int  `xml:"EndLine,attr"`
+	Covered   bool `xml:"Covered,attr"`
 }
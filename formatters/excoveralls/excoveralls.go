@@ -14,7 +14,8 @@ import (
 var searchPaths = []string{"cover/excoveralls.json"}
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -49,7 +50,7 @@ func (r Formatter) Format() (formatters.Report, error) {
 
 	gitHead, _ := env.GetHead()
 	for _, file := range coverageInput.Files {
-		sourceFile, err := formatters.NewSourceFile(file.Name, gitHead)
+		sourceFile, err := formatters.NewSourceFile(file.Name, gitHead, r.Options)
 		if err != nil {
 			return report, errors.WithStack(err)
 		}
@@ -0,0 +1,175 @@
+package formatters
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// FileFilter decides which source files make it into a report. When
+// Include has patterns, only the files matching one of them are kept,
+// and of those, the files matching any Exclude pattern are left out.
+//
+// Patterns are globs matched against the whole file name, in which "*"
+// matches within a path segment, "**" matches any number of segments,
+// and "{a,b}" matches either alternative.
+type FileFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// NewFileFilter checks the patterns are valid globs.
+func NewFileFilter(include []string, exclude []string) (FileFilter, error) {
+	for _, p := range append(append([]string{}, include...), exclude...) {
+		for _, alt := range expandBraces(p) {
+			if _, err := path.Match(alt, ""); err != nil {
+				return FileFilter{}, errors.Errorf("invalid file pattern %q", p)
+			}
+		}
+	}
+	return FileFilter{Include: include, Exclude: exclude}, nil
+}
+
+// Match returns whether the file is kept. If it isn't, reason is the
+// exclude pattern that matched it, or "" when no include pattern did.
+func (f FileFilter) Match(name string) (keep bool, reason string) {
+	name = filepath.ToSlash(name)
+	if len(f.Include) > 0 {
+		included := false
+		for _, p := range f.Include {
+			if MatchGlob(p, name) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, ""
+		}
+	}
+	for _, p := range f.Exclude {
+		if MatchGlob(p, name) {
+			return false, p
+		}
+	}
+	return true, ""
+}
+
+// MatchGlob reports whether name matches the pattern, with "**" matching
+// any number of path segments.
+func MatchGlob(pattern, name string) bool {
+	for _, p := range expandBraces(pattern) {
+		if matchSegments(strings.Split(p, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(patterns []string, parts []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			patterns = patterns[1:]
+			if len(patterns) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(patterns, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(patterns[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		patterns = patterns[1:]
+		parts = parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// expandBraces turns "src/{a,b}/*.go" into "src/a/*.go" and "src/b/*.go".
+func expandBraces(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	if start < 0 {
+		return []string{pattern}
+	}
+	end := strings.Index(pattern[start:], "}")
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += start
+
+	expanded := []string{}
+	for _, alt := range strings.Split(pattern[start+1:end], ",") {
+		expanded = append(expanded, expandBraces(pattern[:start]+alt+pattern[end+1:])...)
+	}
+	return expanded
+}
+
+// Filter leaves out of the report the files f doesn't keep and recounts
+// the lines of the rest. NewSourceFile filters the files of new reports;
+// this is for reports read back from JSON.
+func (rep *Report) Filter(f FileFilter) error {
+	files := rep.SourceFiles
+	rep.SourceFiles = SourceFiles{}
+	rep.LineCounts = LineCounts{}
+	rep.BranchCounts = LineCounts{}
+	rep.FunctionCounts = LineCounts{}
+	rep.CoveredPercent = 0
+	rep.BranchCoveredPercent = 0
+	for name, sf := range files {
+		if keep, pattern := f.Match(name); !keep {
+			rep.exclude(name, pattern)
+			continue
+		}
+		if err := rep.AddSourceFile(sf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exclude records that a file was left out of the report.
+func (rep *Report) exclude(name string, pattern string) {
+	if pattern == "" {
+		logrus.Debugf("leaving out %s, it matches none of the include patterns", name)
+		rep.NotIncludedFiles = insertSorted(rep.NotIncludedFiles, name)
+		return
+	}
+	logrus.Debugf("leaving out %s, it matches the exclude pattern %s", name, pattern)
+	if rep.ExcludedFiles == nil {
+		rep.ExcludedFiles = map[string][]string{}
+	}
+	rep.ExcludedFiles[pattern] = insertSorted(rep.ExcludedFiles[pattern], name)
+}
+
+func (rep *Report) mergeExcluded(r *Report) {
+	for _, name := range r.NotIncludedFiles {
+		rep.exclude(name, "")
+	}
+	for pattern, names := range r.ExcludedFiles {
+		for _, name := range names {
+			rep.exclude(name, pattern)
+		}
+	}
+}
+
+// insertSorted adds s to the sorted list unless it's already there.
+func insertSorted(list []string, s string) []string {
+	i := sort.SearchStrings(list, s)
+	if i < len(list) && list[i] == s {
+		return list
+	}
+	list = append(list, "")
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	return list
+}
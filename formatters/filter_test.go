@@ -0,0 +1,83 @@
+package formatters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchGlob(t *testing.T) {
+	r := require.New(t)
+
+	tt := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"vendor/**", "vendor/github.com/pkg/errors/errors.go", true},
+		{"vendor/**", "src/vendor/a.go", false},
+		{"**/vendor/**", "src/vendor/a.go", true},
+		{"**/*_test.go", "a_test.go", true},
+		{"**/*_test.go", "pkg/sub/a_test.go", true},
+		{"*.go", "pkg/a.go", false},
+		{"src/**/*.pb.go", "src/api/v1/api.pb.go", true},
+		{"src/**/*.pb.go", "src/api.pb.go", true},
+		{"src/*/*.js", "src/a/b/c.js", false},
+		{"**/*.{js,ts}", "lib/index.ts", true},
+		{"**/*.{js,ts}", "lib/index.rb", false},
+		{"lib/?.rb", "lib/a.rb", true},
+	}
+
+	for _, tc := range tt {
+		r.Equal(tc.match, MatchGlob(tc.pattern, tc.name), "%s ~ %s", tc.pattern, tc.name)
+	}
+}
+
+func Test_FileFilter_Match(t *testing.T) {
+	r := require.New(t)
+
+	_, err := NewFileFilter([]string{"src/[a"}, nil)
+	r.Error(err)
+
+	f, err := NewFileFilter([]string{"src/**", "lib/**"}, []string{"**/*_test.go", "src/gen/**"})
+	r.NoError(err)
+
+	keep, reason := f.Match("src/a.go")
+	r.True(keep)
+	r.Equal("", reason)
+
+	keep, reason = f.Match("vendor/a.go")
+	r.False(keep)
+	r.Equal("", reason)
+
+	keep, reason = f.Match("src/a_test.go")
+	r.False(keep)
+	r.Equal("**/*_test.go", reason)
+
+	keep, reason = f.Match("src/gen/a.go")
+	r.False(keep)
+	r.Equal("src/gen/**", reason)
+}
+
+func Test_Report_Filter(t *testing.T) {
+	r := require.New(t)
+
+	f, err := NewFileFilter(nil, []string{"vendor/**"})
+	r.NoError(err)
+
+	rep := Report{SourceFiles: SourceFiles{}}
+	r.NoError(rep.AddSourceFile(SourceFile{Name: "a.go", Coverage: Coverage{NewNullInt(1)}}))
+	r.NoError(rep.AddSourceFile(SourceFile{Name: "vendor/b.go", Coverage: Coverage{NewNullInt(0)}}))
+	r.Equal(2, rep.LineCounts.Total)
+
+	r.NoError(rep.Filter(f))
+	r.Len(rep.SourceFiles, 1)
+	r.Equal(1, rep.LineCounts.Total)
+	r.InDelta(100, rep.CoveredPercent, 0.01)
+	r.Equal(map[string][]string{"vendor/**": {"vendor/b.go"}}, rep.ExcludedFiles)
+
+	other := Report{SourceFiles: SourceFiles{}, ExcludedFiles: map[string][]string{"vendor/**": {"vendor/a.go", "vendor/b.go"}}, NotIncludedFiles: []string{"c.go"}}
+	r.NoError(rep.Merge(&other))
+	r.Equal([]string{"vendor/a.go", "vendor/b.go"}, rep.ExcludedFiles["vendor/**"])
+	r.Equal([]string{"c.go"}, rep.NotIncludedFiles)
+}
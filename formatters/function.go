@@ -0,0 +1,58 @@
+package formatters
+
+import "sort"
+
+// Function is a function or method defined in a source file. EndLine is
+// zero when the coverage tool only reports where the function starts.
+type Function struct {
+	Name      string `json:"name"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Hits      int    `json:"hits"`
+}
+
+type Functions []Function
+
+// Merge sums the hits of functions found in both a and b and keeps the
+// functions only one of them knows about.
+func (a Functions) Merge(b Functions) Functions {
+	if len(b) == 0 {
+		return a
+	}
+
+	merged := make(Functions, 0, len(a)+len(b))
+	index := map[Function]int{}
+	for _, fn := range append(append(Functions{}, a...), b...) {
+		key := Function{Name: fn.Name, StartLine: fn.StartLine}
+		if i, ok := index[key]; ok {
+			merged[i].Hits += fn.Hits
+			if fn.EndLine > merged[i].EndLine {
+				merged[i].EndLine = fn.EndLine
+			}
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, fn)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].StartLine < merged[j].StartLine
+	})
+	return merged
+}
+
+// Counts tallies the functions the same way lines are tallied: a function
+// that was called at least once is covered.
+func (fs Functions) Counts() LineCounts {
+	lc := LineCounts{}
+	for _, fn := range fs {
+		lc.Total++
+		lc.Strength += fn.Hits
+		if fn.Hits == 0 {
+			lc.Missed++
+			continue
+		}
+		lc.Covered++
+	}
+	return lc
+}
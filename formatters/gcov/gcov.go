@@ -3,7 +3,6 @@ package gcov
 import (
 	"bufio"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,34 +11,76 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/codeclimate/test-reporter/env"
 	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/gobuffalo/envy"
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
-// Formatter collects GCov files, parses them, then formats them into a single report.
+// Formatter collects GCov files, both the text files and the JSON
+// intermediate files, parses them, then formats them into a single report.
 type Formatter struct {
 	FileNames []string
+	Options   formatters.SourceFileOptions
 }
 
 var searchPaths = []string{"./"}
 var search = ".gcov" // look for these file extensions
 
-// Search searches the designated paths for GCov files,
-// appending them to the list of filenames.
+// skipped wherever they are, on top of GCOV_IGNORE
+var defaultIgnores = []string{"**/.git", "**/node_modules"}
+
+// ignorePatterns are the globs in GCOV_IGNORE, separated by white space,
+// and the default ones.
+func ignorePatterns() []string {
+	return append(strings.Fields(envy.Get("GCOV_IGNORE", "")), defaultIgnores...)
+}
+
+// Search looks in the designated paths, or "./" if there are none, for
+// GCov files, appending them to the list of filenames. When GCOV_IGNORE is
+// set, it walks the directories in them too, skipping the files and
+// directories matching an ignore pattern, relative to the search path they
+// were found in.
 func (f *Formatter) Search(paths ...string) (string, error) {
-	paths = append(paths, searchPaths...)
+	if len(paths) == 0 {
+		paths = searchPaths
+	}
+	recursive := envy.Get("GCOV_IGNORE", "") != ""
+	ignores := ignorePatterns()
 
+	seen := map[string]bool{}
 	for _, p := range paths {
 		logrus.Debugf("checking search path %s for GCov formatter", p)
-		files, err := ioutil.ReadDir(p)
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if rel, err := filepath.Rel(p, path); err == nil && rel != "." && isIgnored(filepath.ToSlash(rel), ignores) {
+				logrus.Debugf("ignoring %s", path)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				if path != p && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// a file given as a search path is taken as it is
+			if path != p && !strings.HasSuffix(path, search) && !strings.HasSuffix(path, jsonSearch) {
+				return nil
+			}
+			path = filepath.Clean(path)
+			if !seen[path] {
+				seen[path] = true
+				f.FileNames = append(f.FileNames, path)
+			}
+			return nil
+		})
 		if err != nil {
 			return "", errors.WithStack(err)
 		}
-		for _, file := range files {
-			if !file.IsDir() && strings.HasSuffix(file.Name(), search) {
-				f.FileNames = append(f.FileNames, filepath.Join(p, file.Name()))
-			}
-		}
 	}
 
 	if len(f.FileNames) == 0 {
@@ -49,6 +90,15 @@ func (f *Formatter) Search(paths ...string) (string, error) {
 	return fmt.Sprint(f.FileNames), nil
 }
 
+func isIgnored(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if formatters.MatchGlob(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
 // Format combines the source files into a report.
 func (f *Formatter) Format() (formatters.Report, error) {
 	rep, err := formatters.NewReport()
@@ -58,7 +108,21 @@ func (f *Formatter) Format() (formatters.Report, error) {
 
 	gitHead, _ := env.GetHead()
 	for _, file := range f.FileNames {
-		sf, err := parseSourceFile(file, gitHead)
+		if strings.HasSuffix(file, jsonSearch) {
+			files, err := parseJSONFile(file, gitHead, f.Options)
+			if err != nil {
+				return rep, err
+			}
+			for _, sf := range files {
+				err = rep.AddSourceFile(sf)
+				if err != nil {
+					return rep, errors.WithStack(err)
+				}
+			}
+			continue
+		}
+
+		sf, err := parseSourceFile(file, gitHead, f.Options)
 		if err != nil {
 			return rep, errors.WithStack(err)
 		}
@@ -71,18 +135,57 @@ func (f *Formatter) Format() (formatters.Report, error) {
 	return rep, nil
 }
 
-// Parse a single GCov source file.
-func parseSourceFile(fileName string, gitHead *object.Commit) (formatters.SourceFile, error) {
-	var sf formatters.SourceFile
-	sourceFileName, err := getSourceFileName(fileName)
-	if err != nil {
-		return sf, errors.WithStack(err)
+// lines of generated sources can be long, so allow for more than the
+// scanner's default 64KB
+const maxLineLength = 1024 * 1024
+
+// preamble is what gcov writes on line 0 of a file, before the source:
+// the source file, the notes and data files the counts came from, and how
+// many times the program ran.
+type preamble struct {
+	Source string
+	Graph  string
+	Data   string
+	Runs   int
+}
+
+func (p *preamble) parse(entry string) {
+	split := strings.SplitN(entry, ":", 2)
+	if len(split) != 2 {
+		return
 	}
+	value := strings.TrimSpace(split[1])
+	switch split[0] {
+	case "Source":
+		p.Source = value
+	case "Graph":
+		p.Graph = value
+	case "Data":
+		p.Data = value
+	case "Runs":
+		p.Runs, _ = strconv.Atoi(value)
+	}
+}
 
-	sf, err = formatters.NewSourceFile(sourceFileName, gitHead)
-	if err != nil {
-		return sf, errors.WithStack(err)
+// sourceFileName is the path of the source. gcov writes it relative to
+// where it ran, which is usually where the .gcov file is, so that's tried
+// first; the same source covered from several object directories then
+// gets the same name.
+func (p preamble) sourceFileName(coverageFileName string) string {
+	if filepath.IsAbs(p.Source) {
+		return p.Source
+	}
+	name := filepath.Join(filepath.Dir(coverageFileName), p.Source)
+	if _, err := os.Stat(name); err == nil {
+		return name
 	}
+	return p.Source
+}
+
+// Parse a single GCov source file, one line at a time. Lines numbered 0
+// are the preamble, the rest the source with its counts.
+func parseSourceFile(fileName string, gitHead *object.Commit, opts formatters.SourceFileOptions) (formatters.SourceFile, error) {
+	var sf formatters.SourceFile
 
 	coverageFile, err := os.Open(fileName)
 	if err != nil {
@@ -91,59 +194,70 @@ func parseSourceFile(fileName string, gitHead *object.Commit) (formatters.Source
 	defer coverageFile.Close()
 
 	scanner := bufio.NewScanner(coverageFile)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
 
+	pre := preamble{}
+	coverage := formatters.Coverage{}
 	for scanner.Scan() {
-		line := scanner.Text()
-
-		split := strings.SplitN(string(line), ":", 3)
+		split := strings.SplitN(scanner.Text(), ":", 3)
 		if len(split) != 3 {
 			continue
 		}
 
-		coverage := strings.TrimSpace(split[0])
-		lineNum, _ := strconv.Atoi(strings.TrimSpace(split[1]))
-		if lineNum < 1 { // pre code metadata
+		lineNum, err := strconv.Atoi(strings.TrimSpace(split[1]))
+		if err != nil || lineNum < 0 {
+			continue
+		}
+		if lineNum == 0 {
+			pre.parse(split[2])
 			continue
 		}
 
-		switch coverage {
-		case "-":
-			sf.Coverage = append(sf.Coverage, formatters.NullInt{})
-		case "#####":
-			sf.Coverage = append(sf.Coverage, formatters.NewNullInt(0))
-		default: // coverage is number of hits
-			num, err := strconv.Atoi(coverage)
-			if err != nil {
-				return sf, errors.WithStack(err)
-			}
-			sf.Coverage = append(sf.Coverage, formatters.NewNullInt(num))
+		// the lines of templates and inline functions are repeated for
+		// each instantiation after their total
+		if lineNum <= len(coverage) {
+			continue
+		}
+		count, err := parseCount(strings.TrimSpace(split[0]))
+		if err != nil {
+			return sf, errors.Wrapf(err, "could not read the count of line %d in %s", lineNum, fileName)
+		}
+		for len(coverage) < lineNum-1 {
+			coverage = append(coverage, formatters.NullInt{})
 		}
+		coverage = append(coverage, count)
+	}
+	if err := scanner.Err(); err != nil {
+		return sf, errors.WithStack(err)
+	}
 
+	if pre.Source == "" {
+		return sf, errors.WithStack(errors.Errorf("Could not find source file name: %s", fileName))
 	}
+	logrus.Debugf("%s covers %s from %s and %s over %d runs", fileName, pre.Source, pre.Graph, pre.Data, pre.Runs)
 
+	sf, err = formatters.NewSourceFile(pre.sourceFileName(fileName), gitHead, opts)
+	if err != nil {
+		return sf, errors.WithStack(err)
+	}
+	sf.Coverage = coverage
 	return sf, nil
 }
 
-func getSourceFileName(coverageFileName string) (string, error) {
-	coverageFile, err := os.Open(coverageFileName)
-	if err != nil {
-		return "", errors.WithStack(err)
+// parseCount reads the count gcov gives a line. "-" marks lines without
+// code, "#####" and "%%%%%" lines that never ran, and "=====" and "$$$$$"
+// lines only reached by exceptions that never ran either. A "*" after a
+// count marks a line with blocks that never ran.
+func parseCount(count string) (formatters.NullInt, error) {
+	switch count {
+	case "-":
+		return formatters.NullInt{}, nil
+	case "#####", "%%%%%", "=====", "$$$$$":
+		return formatters.NewNullInt(0), nil
 	}
-	defer coverageFile.Close()
-
-	scanner := bufio.NewScanner(coverageFile)
-	var sourceFileName string
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		split := strings.SplitN(string(line), ":", 4)
-		if len(split) != 4 {
-			return "", errors.WithStack(errors.Errorf("Could not find source file name: %s", coverageFile.Name() ))
-		}
-		sourceFileName = strings.TrimSpace(split[3])
-		return sourceFileName, nil
+	num, err := strconv.Atoi(strings.TrimSuffix(count, "*"))
+	if err != nil {
+		return formatters.NullInt{}, errors.WithStack(err)
 	}
-
-	return sourceFileName, err
-}
\ No newline at end of file
+	return formatters.NewNullInt(num), nil
+}
@@ -3,7 +3,11 @@ package gcov
 import (
 	"testing"
 
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
 	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/gobuffalo/envy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +52,80 @@ func testReport(r *require.Assertions, f *Formatter) {
 	report, _ := f.Format()
 	r.InDelta(71.7, report.CoveredPercent, 1)
 }
+
+func TestParseJSON(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{}
+	_, err := f.Search("json_example")
+	r.NoError(err)
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+
+	// the names are relative to current_working_directory
+	sf := rep.SourceFiles["/home/ci/project/src/main.c"]
+	r.Len(sf.Coverage, 15)
+	r.False(sf.Coverage[0].Valid)
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[3])
+	r.Equal(formatters.NewNullInt(4), sf.Coverage[11])
+	r.Equal(formatters.NewNullInt(3), sf.Coverage[12])
+	r.Equal(8, sf.LineCounts.Total)
+	r.Equal(6, sf.LineCounts.Covered)
+	r.Equal(formatters.Functions{
+		{Name: "unused", StartLine: 4, EndLine: 7, Hits: 0},
+		{Name: "main", StartLine: 9, EndLine: 16, Hits: 1},
+	}, sf.Functions)
+	r.Equal(formatters.Branches{
+		{Line: 12, ID: "0", Taken: 3},
+		{Line: 12, ID: "1", Taken: 1},
+	}, sf.Branches)
+
+	sf = rep.SourceFiles["/home/ci/project/include/util.h"]
+	r.Equal(6, sf.LineCounts.Total)
+	r.Equal(5, sf.LineCounts.Covered)
+	r.Equal(3, sf.BranchCounts.Covered)
+	r.Equal(1, sf.BranchCounts.Missed)
+}
+
+func TestParseTree(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	// the files are in subdirectories
+	_, err := (&Formatter{}).Search("tree_example")
+	r.Error(err)
+
+	envy.Temp(func() {
+		envy.Set("GCOV_IGNORE", "build/_deps")
+
+		f := &Formatter{}
+		_, err := f.Search("tree_example")
+		r.NoError(err)
+		r.Equal([]string{"tree_example/build/a/util.c.gcov", "tree_example/build/b/util.c.gcov"}, f.FileNames)
+		rep, err := f.Format()
+		r.NoError(err)
+		r.Len(rep.SourceFiles, 1)
+
+		// both object directories cover the same source
+		sf := rep.SourceFiles["tree_example/src/util.c"]
+		r.Len(sf.Coverage, 10)
+		r.False(sf.Coverage[0].Valid)
+		r.Equal(formatters.NewNullInt(8), sf.Coverage[2])
+		r.Equal(formatters.NewNullInt(1), sf.Coverage[5])
+		r.Equal(formatters.NewNullInt(7), sf.Coverage[6])
+		r.Equal(formatters.NewNullInt(0), sf.Coverage[7])
+		r.Equal(6, sf.LineCounts.Total)
+		r.Equal(5, sf.LineCounts.Covered)
+	})
+}
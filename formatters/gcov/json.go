@@ -0,0 +1,121 @@
+package gcov
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// the extension of the files written by gcov --json-format, GCC 9 and up
+var jsonSearch = ".gcov.json.gz"
+
+type jsonDocument struct {
+	FormatVersion    string     `json:"format_version"`
+	WorkingDirectory string     `json:"current_working_directory"`
+	Files            []jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	File      string `json:"file"`
+	Functions []struct {
+		Name           string `json:"name"`
+		DemangledName  string `json:"demangled_name"`
+		StartLine      int    `json:"start_line"`
+		EndLine        int    `json:"end_line"`
+		ExecutionCount int    `json:"execution_count"`
+	} `json:"functions"`
+	Lines []struct {
+		LineNumber int `json:"line_number"`
+		Count      int `json:"count"`
+		Branches   []struct {
+			Count int `json:"count"`
+		} `json:"branches"`
+	} `json:"lines"`
+}
+
+// parseJSONFile reads a gzipped gcov JSON intermediate file, which has the
+// coverage of every source file a compilation unit used. Relative source
+// names are relative to the directory gcov was run in.
+func parseJSONFile(fileName string, gitHead *object.Commit, opts formatters.SourceFileOptions) ([]formatters.SourceFile, error) {
+	files := []formatters.SourceFile{}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return files, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return files, errors.Wrapf(err, "could not decompress %s", fileName)
+	}
+	defer gz.Close()
+
+	doc := jsonDocument{}
+	if err := json.NewDecoder(gz).Decode(&doc); err != nil {
+		return files, errors.Wrapf(err, "could not read %s", fileName)
+	}
+	if doc.FormatVersion == "" {
+		return files, errors.Errorf("%s is not a gcov JSON intermediate file", fileName)
+	}
+
+	for _, jf := range doc.Files {
+		name := jf.File
+		if doc.WorkingDirectory != "" && !filepath.IsAbs(name) {
+			name = filepath.Join(doc.WorkingDirectory, name)
+		}
+
+		sf, err := formatters.NewSourceFile(name, gitHead, opts)
+		if err != nil {
+			return files, errors.WithStack(err)
+		}
+
+		// a line shows up once for each instance of the function it's in,
+		// as with templates and inline functions, so the counts are added
+		hits := map[int]int{}
+		branches := map[int]int{}
+		last := 0
+		for _, l := range jf.Lines {
+			if l.LineNumber < 1 {
+				continue
+			}
+			hits[l.LineNumber] += l.Count
+			if l.LineNumber > last {
+				last = l.LineNumber
+			}
+			for _, b := range l.Branches {
+				sf.Branches = append(sf.Branches, formatters.Branch{
+					Line:  l.LineNumber,
+					ID:    strconv.Itoa(branches[l.LineNumber]),
+					Taken: b.Count,
+				})
+				branches[l.LineNumber]++
+			}
+		}
+		sf.Coverage = make(formatters.Coverage, last)
+		for l, h := range hits {
+			sf.Coverage[l-1] = formatters.NewNullInt(h)
+		}
+
+		for _, fn := range jf.Functions {
+			name := fn.DemangledName
+			if name == "" {
+				name = fn.Name
+			}
+			sf.Functions = append(sf.Functions, formatters.Function{
+				Name:      name,
+				StartLine: fn.StartLine,
+				EndLine:   fn.EndLine,
+				Hits:      fn.ExecutionCount,
+			})
+		}
+		files = append(files, sf)
+	}
+	return files, nil
+}
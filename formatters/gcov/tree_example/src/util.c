@@ -0,0 +1,10 @@
+#include <stdexcept>
+
+int clamp(int v, int lo, int hi)
+{
+  if (v < lo)
+    return lo;
+  if (v > hi)
+    throw std::out_of_range("v");
+  return v;
+}
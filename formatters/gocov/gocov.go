@@ -14,6 +14,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+// basePackage is used for GOPATH builds, where there is no go.mod to tell
+// where the packages in a coverprofile live.
 var basePackage string
 
 func init() {
@@ -26,7 +28,8 @@ func init() {
 var searchPaths = []string{"c.out"}
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -43,41 +46,52 @@ func (f *Formatter) Search(paths ...string) (string, error) {
 }
 
 func (r Formatter) Format() (formatters.Report, error) {
+	profiles, err := cover.ParseProfiles(r.Path)
+	if err != nil {
+		return formatters.Report{}, errors.WithStack(err)
+	}
+	return FormatProfiles(profiles, filepath.Dir(r.Path), r.Options)
+}
+
+// FormatProfiles turns coverprofile blocks, sorted by position within
+// each file, into a report. The go.work or go.mod used to find the files
+// is looked for from dir, and opts are passed on to NewSourceFile.
+func FormatProfiles(profiles []*cover.Profile, dir string, opts formatters.SourceFileOptions) (formatters.Report, error) {
 	rep, err := formatters.NewReport()
 	if err != nil {
 		return rep, err
 	}
 
-	profiles, err := cover.ParseProfiles(r.Path)
+	modules, err := loadModules(dir)
 	if err != nil {
 		return rep, errors.WithStack(err)
 	}
 
 	gitHead, _ := env.GetHead()
+	precise := preciseLines()
+	skipped := 0
 	for _, p := range profiles {
 		n := strings.TrimPrefix(filepath.FromSlash(p.FileName), basePackage+string(os.PathSeparator))
-		sf, err := formatters.NewSourceFile(n, gitHead)
-		if err != nil {
-			return rep, errors.WithStack(err)
-		}
-		blocks := []cover.ProfileBlock{}
-		for _, b := range p.Blocks {
-			lstIdx := len(blocks) - 1
-			if lstIdx < 0 || blocks[lstIdx].StartLine != b.StartLine || blocks[lstIdx].EndLine != b.EndLine {
-				blocks = append(blocks, b)
+		path := n
+		if modules != nil {
+			var ok bool
+			if n, path, ok = modules.resolve(p.FileName); !ok {
+				logrus.Debugf("skipping %s, it isn't in any of the repository's modules", p.FileName)
+				skipped++
 				continue
 			}
-			blocks[lstIdx].Count += b.Count
 		}
-		lineNum := 1
-		for _, b := range blocks {
-			for lineNum < b.StartLine {
-				sf.Coverage = append(sf.Coverage, formatters.NullInt{})
-				lineNum++
-			}
-			for lineNum <= b.EndLine {
-				sf.Coverage = append(sf.Coverage, formatters.NewNullInt(b.Count))
-				lineNum++
+		sf, err := formatters.NewSourceFile(n, gitHead, opts)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		sf.Coverage = blockCoverage(p.Blocks)
+		if precise {
+			coverage, err := preciseCoverage(path, p.Blocks)
+			if err != nil {
+				logrus.Warnf("could not read the source of %s, counting every line of its blocks: %s", n, err)
+			} else {
+				sf.Coverage = coverage
 			}
 		}
 		err = rep.AddSourceFile(sf)
@@ -86,5 +100,8 @@ func (r Formatter) Format() (formatters.Report, error) {
 		}
 	}
 
+	if skipped > 0 {
+		logrus.Warnf("skipped coverage of %d files from modules outside the repository", skipped)
+	}
 	return rep, nil
 }
@@ -1,6 +1,7 @@
 package gocov
 
 import (
+	"os"
 	"testing"
 
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/gobuffalo/envy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,7 +29,7 @@ func Test_Parse(t *testing.T) {
 
 		r.Len(rep.SourceFiles, 4)
 
-		sf := rep.SourceFiles[filepath.FromSlash("github.com/codeclimate/test-reporter/formatters/source_file.go")]
+		sf := rep.SourceFiles[filepath.FromSlash("formatters/source_file.go")]
 
 		r.InDelta(75.8, sf.CoveredPercent, 1)
 		r.Len(sf.Coverage, 115)
@@ -60,11 +62,89 @@ func Test_Parse(t *testing.T) {
 
 		r.Len(rep.SourceFiles, 2)
 
-		sfFoo := rep.SourceFiles[filepath.Join("example","foo","foo.go")]
-		sfBar := rep.SourceFiles[filepath.Join("example","bar","bar.go")]
+		sfFoo := rep.SourceFiles[filepath.Join("formatters", "gocov", "example", "foo", "foo.go")]
+		sfBar := rep.SourceFiles[filepath.Join("formatters", "gocov", "example", "bar", "bar.go")]
 
 		r.EqualValues(85, rep.CoveredPercent)
 		r.EqualValues(100, sfFoo.CoveredPercent)
 		r.InDelta(66.66, sfBar.CoveredPercent, 0.01)
 	})
+
+	t.Run("should only count the lines with statements in precise mode", func(t *testing.T) {
+		gb := env.GitBlob
+		defer func() { env.GitBlob = gb }()
+		env.GitBlob = func(s string, c *object.Commit) (string, error) {
+			return s, nil
+		}
+
+		r := require.New(t)
+
+		envy.Temp(func() {
+			envy.Set("GOCOV_PRECISE_LINES", "true")
+
+			f := &Formatter{Path: filepath.Join("example", "foobar_test.out")}
+			rep, err := f.Format()
+			r.NoError(err)
+
+			sfFoo := rep.SourceFiles[filepath.Join("formatters", "gocov", "example", "foo", "foo.go")]
+			r.Len(sfFoo.Coverage, 21)
+			r.Equal(5, sfFoo.LineCounts.Total)
+			r.False(sfFoo.Coverage[12].Valid)
+			r.Equal(2, sfFoo.Coverage[16].Int)
+			r.Equal(1, sfFoo.Coverage[17].Int)
+			r.False(sfFoo.Coverage[18].Valid)
+
+			// the same statements as go tool cover -func
+			r.InDelta(87.5, rep.CoveredPercent, 0.01)
+		})
+	})
+}
+
+func Test_Parse_Workspace(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+	pwd, err := os.Getwd()
+	r.NoError(err)
+	dir := t.TempDir()
+	r.NoError(os.Chdir(dir))
+	defer os.Chdir(pwd)
+
+	files := map[string]string{
+		"go.work":         "go 1.21\n\nuse (\n\t./api\n\t./worker // jobs\n)\n",
+		"api/go.mod":      "module example.com/app/api\n\nreplace example.com/app/shared => ../lib/shared\n",
+		"worker/go.mod":   "module example.com/app/worker\n\nreplace (\n\texample.com/fork v1.2.0 => \"../../fork\"\n\texample.com/dep => example.com/other v1.0.0\n)\n",
+		"lib/shared/x.go": "",
+		"c.out": "mode: set\n" +
+			"example.com/app/api/server.go:1.1,3.2 1 1\n" +
+			"example.com/app/worker/jobs/run.go:2.1,2.9 1 0\n" +
+			"example.com/app/shared/x.go:1.1,1.9 1 1\n" +
+			"example.com/fork/f.go:1.1,1.9 1 1\n" +
+			"example.com/dep/d.go:1.1,1.9 1 1\n",
+	}
+	r.NoError(os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	for name, content := range files {
+		r.NoError(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		r.NoError(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	envy.Temp(func() {
+		envy.Set("GIT_BRANCH", "master")
+		envy.Set("GIT_COMMIT_SHA", "a12345")
+		envy.Set("GIT_COMMITTED_AT", "1234")
+		envy.Set("GOWORK", "")
+
+		f := &Formatter{Path: "c.out"}
+		rep, err := f.Format()
+		r.NoError(err)
+
+		r.Len(rep.SourceFiles, 3)
+		r.Contains(rep.SourceFiles, filepath.Join("api", "server.go"))
+		r.Contains(rep.SourceFiles, filepath.Join("worker", "jobs", "run.go"))
+		r.Contains(rep.SourceFiles, filepath.Join("lib", "shared", "x.go"))
+	})
 }
@@ -0,0 +1,121 @@
+package gocov
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+)
+
+// preciseLines is whether to read the Go source of each file to find the
+// lines with statements, rather than counting every line of every block,
+// blank lines and closing braces included. It's set by the
+// GOCOV_PRECISE_LINES environment variable.
+func preciseLines() bool {
+	precise, _ := strconv.ParseBool(envy.Get("GOCOV_PRECISE_LINES", "false"))
+	return precise
+}
+
+// blockCoverage gives every line of a block its count.
+func blockCoverage(profileBlocks []cover.ProfileBlock) formatters.Coverage {
+	blocks := []cover.ProfileBlock{}
+	for _, b := range profileBlocks {
+		lstIdx := len(blocks) - 1
+		if lstIdx < 0 || blocks[lstIdx].StartLine != b.StartLine || blocks[lstIdx].EndLine != b.EndLine {
+			blocks = append(blocks, b)
+			continue
+		}
+		blocks[lstIdx].Count += b.Count
+	}
+
+	coverage := formatters.Coverage{}
+	lineNum := 1
+	for _, b := range blocks {
+		for lineNum < b.StartLine {
+			coverage = append(coverage, formatters.NullInt{})
+			lineNum++
+		}
+		for lineNum <= b.EndLine {
+			coverage = append(coverage, formatters.NewNullInt(b.Count))
+			lineNum++
+		}
+	}
+	return coverage
+}
+
+// preciseCoverage parses the Go file at path and gives each line that a
+// statement starts on the count of the block holding that statement. A
+// line with statements from several blocks, such as "if err != nil {
+// return err }", gets the smallest of their counts, so it only shows as
+// covered if all of it ran.
+func preciseCoverage(path string, profileBlocks []cover.ProfileBlock) (formatters.Coverage, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// blocks with the same range come from several test binaries covering
+	// the same package
+	blocks := []cover.ProfileBlock{}
+	seen := map[[4]int]int{}
+	for _, b := range profileBlocks {
+		r := [4]int{b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+		if i, ok := seen[r]; ok {
+			blocks[i].Count += b.Count
+			continue
+		}
+		seen[r] = len(blocks)
+		blocks = append(blocks, b)
+	}
+	byLine := map[int][]int{}
+	for i, b := range blocks {
+		for l := b.StartLine; l <= b.EndLine; l++ {
+			byLine[l] = append(byLine[l], i)
+		}
+	}
+
+	counts := map[int]int{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(ast.Stmt)
+		if !ok {
+			return true
+		}
+		switch stmt.(type) {
+		case *ast.BlockStmt, *ast.EmptyStmt, *ast.LabeledStmt, *ast.CaseClause, *ast.CommClause:
+			return true
+		}
+
+		pos := fset.Position(stmt.Pos())
+		for _, i := range byLine[pos.Line] {
+			if !contains(blocks[i], pos) {
+				continue
+			}
+			if c, ok := counts[pos.Line]; !ok || blocks[i].Count < c {
+				counts[pos.Line] = blocks[i].Count
+			}
+		}
+		return true
+	})
+
+	coverage := make(formatters.Coverage, fset.File(file.Pos()).LineCount())
+	for l, c := range counts {
+		if l <= len(coverage) {
+			coverage[l-1] = formatters.NewNullInt(c)
+		}
+	}
+	return coverage, nil
+}
+
+func contains(b cover.ProfileBlock, pos token.Position) bool {
+	if pos.Line < b.StartLine || pos.Line == b.StartLine && pos.Column < b.StartCol {
+		return false
+	}
+	return pos.Line < b.EndLine || pos.Line == b.EndLine && pos.Column < b.EndCol
+}
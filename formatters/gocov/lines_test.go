@@ -0,0 +1,46 @@
+package gocov
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_preciseCoverage(t *testing.T) {
+	r := require.New(t)
+
+	src := `package x
+
+func Check(err error) error {
+	if err != nil { return err }
+
+	// nothing went wrong
+	return nil
+}
+`
+	path := filepath.Join(t.TempDir(), "x.go")
+	r.NoError(os.WriteFile(path, []byte(src), 0644))
+
+	coverage, err := preciseCoverage(path, []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 29, EndLine: 4, EndCol: 16, NumStmt: 1, Count: 3},
+		{StartLine: 4, StartCol: 16, EndLine: 4, EndCol: 30, NumStmt: 1, Count: 0},
+		{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 12, NumStmt: 1, Count: 3},
+	})
+	r.NoError(err)
+	r.Len(coverage, 8)
+
+	// the if ran, the return in it didn't
+	r.True(coverage[3].Valid)
+	r.Equal(0, coverage[3].Int)
+	r.False(coverage[4].Valid)
+	r.False(coverage[5].Valid)
+	r.Equal(3, coverage[6].Int)
+	r.False(coverage[7].Valid)
+
+	_, err = preciseCoverage(filepath.Join(t.TempDir(), "missing.go"), nil)
+	r.Error(err)
+}
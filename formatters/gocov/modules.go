@@ -0,0 +1,282 @@
+package gocov
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+)
+
+// module is a Go module whose source lives in the repository.
+type module struct {
+	Path string
+	Dir  string
+}
+
+// moduleMap maps the import paths in a coverprofile to files in the
+// repository, using the go.work or go.mod that the profile was built with.
+type moduleMap struct {
+	root    string
+	modules []module
+}
+
+// loadModules looks for a go.work, then a go.mod, in dir and each of its
+// parents, and then in the working directory and its parents. It returns
+// nil if there is neither.
+func loadModules(dir string) (*moduleMap, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m := &moduleMap{root: repoRoot(cwd)}
+
+	gowork := envy.Get("GOWORK", "")
+	if gowork != "" && gowork != "off" {
+		return m, m.addWork(gowork)
+	}
+
+	starts := []string{cwd}
+	if abs, err := filepath.Abs(dir); err == nil {
+		starts = []string{abs, cwd}
+	}
+	for _, start := range starts {
+		work, mod := findModuleFiles(start)
+		if work != "" && gowork != "off" {
+			return m, m.addWork(work)
+		}
+		if mod != "" {
+			return m, m.addMod(mod)
+		}
+	}
+	return nil, nil
+}
+
+// findModuleFiles returns the go.work the go command would use from dir,
+// if any, and the nearest go.mod.
+func findModuleFiles(dir string) (work string, mod string) {
+	for {
+		if work == "" {
+			if _, err := os.Stat(filepath.Join(dir, "go.work")); err == nil {
+				work = filepath.Join(dir, "go.work")
+			}
+		}
+		if mod == "" {
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				mod = filepath.Join(dir, "go.mod")
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return work, mod
+		}
+		dir = parent
+	}
+}
+
+// repoRoot is the nearest directory containing .git, or dir if there is none.
+func repoRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+func (m *moduleMap) addWork(file string) error {
+	directives, err := parseModFile(file)
+	if err != nil {
+		return err
+	}
+	base := filepath.Dir(file)
+	for _, d := range directives {
+		switch {
+		case d[0] == "use" && len(d) == 2:
+			err = m.addMod(filepath.Join(resolveDir(base, d[1]), "go.mod"))
+		case d[0] == "replace":
+			m.addReplace(base, d[1:])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *moduleMap) addMod(file string) error {
+	directives, err := parseModFile(file)
+	if err != nil {
+		return err
+	}
+	base := filepath.Dir(file)
+	for _, d := range directives {
+		switch {
+		case d[0] == "module" && len(d) == 2:
+			m.add(module{Path: d[1], Dir: base})
+		case d[0] == "replace":
+			m.addReplace(base, d[1:])
+		}
+	}
+	return nil
+}
+
+// addReplace adds the module of a replace directive, "old [version] =>
+// new [version]", if it is replaced by a directory.
+func (m *moduleMap) addReplace(base string, args []string) {
+	arrow := -1
+	for i, a := range args {
+		if a == "=>" {
+			arrow = i
+			break
+		}
+	}
+	// directories are never given a version
+	if arrow < 1 || arrow > 2 || len(args) != arrow+2 || !isLocalPath(args[arrow+1]) {
+		return
+	}
+	m.add(module{Path: args[0], Dir: resolveDir(base, args[arrow+1])})
+}
+
+// add keeps the modules inside the repository, longest import path first.
+func (m *moduleMap) add(mod module) {
+	mod.Dir = filepath.Clean(mod.Dir)
+	if rel, err := filepath.Rel(m.root, mod.Dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		logrus.Debugf("module %s in %s is outside the repository", mod.Path, mod.Dir)
+		return
+	}
+	logrus.Debugf("module %s is in %s", mod.Path, mod.Dir)
+	m.modules = append(m.modules, mod)
+	sort.SliceStable(m.modules, func(i, j int) bool {
+		return len(m.modules[i].Path) > len(m.modules[j].Path)
+	})
+}
+
+// resolve returns the name, relative to the root of the repository, and
+// the path of a file in a coverprofile, or false if it belongs to none of
+// the modules.
+func (m *moduleMap) resolve(fileName string) (string, string, bool) {
+	for _, mod := range m.modules {
+		if !strings.HasPrefix(fileName, mod.Path+"/") {
+			continue
+		}
+		path := filepath.Join(mod.Dir, filepath.FromSlash(strings.TrimPrefix(fileName, mod.Path+"/")))
+		rel, err := filepath.Rel(m.root, path)
+		if err != nil {
+			return "", "", false
+		}
+		return rel, path, true
+	}
+	return "", "", false
+}
+
+func isLocalPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || p == "." || p == ".." || filepath.IsAbs(p) || path.IsAbs(p)
+}
+
+func resolveDir(base string, dir string) string {
+	dir = filepath.FromSlash(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(base, dir)
+}
+
+// parseModFile reads the directives of a go.mod or go.work file, with
+// blocks such as "replace ( ... )" flattened into one directive per line.
+func parseModFile(file string) ([][]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	directives := [][]string{}
+	block := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		tokens, err := modTokens(scanner.Text())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse %s", file)
+		}
+		switch {
+		case len(tokens) == 0:
+		case block != "" && tokens[0] == ")":
+			block = ""
+		case block != "":
+			directives = append(directives, append([]string{block}, tokens...))
+		case len(tokens) == 2 && tokens[1] == "(":
+			block = tokens[0]
+		default:
+			directives = append(directives, tokens)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return directives, nil
+}
+
+// modTokens splits a line of a go.mod file into its tokens, unquoting
+// quoted strings and dropping comments.
+func modTokens(line string) ([]string, error) {
+	tokens := []string{}
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+			return tokens, nil
+		case line[0] == '"' || line[0] == '`':
+			end := strings.IndexByte(line[1:], line[0])
+			if line[0] == '"' {
+				end = quotedEnd(line)
+			}
+			if end < 0 {
+				return nil, errors.Errorf("unterminated string %s", line)
+			}
+			s, err := strconv.Unquote(line[:end+2])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			tokens = append(tokens, s)
+			line = line[end+2:]
+		case strings.HasPrefix(line, "=>"):
+			tokens = append(tokens, "=>")
+			line = line[2:]
+		default:
+			end := strings.IndexAny(line, " \t\r\"`")
+			if c := strings.Index(line, "//"); c >= 0 && (end < 0 || c < end) {
+				end = c
+			}
+			if end < 0 {
+				end = len(line)
+			}
+			tokens = append(tokens, line[:end])
+			line = line[end:]
+		}
+	}
+}
+
+// quotedEnd returns the index, after the opening quote, of the closing
+// quote of the double-quoted string at the start of s.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i - 1
+		}
+	}
+	return -1
+}
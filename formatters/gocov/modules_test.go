@@ -0,0 +1,22 @@
+package gocov
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_modTokens(t *testing.T) {
+	r := require.New(t)
+
+	tokens, err := modTokens(`	example.com/a v1.0.0 => "../a b" // local copy`)
+	r.NoError(err)
+	r.Equal([]string{"example.com/a", "v1.0.0", "=>", "../a b"}, tokens)
+
+	tokens, err = modTokens("module `example.com/m`")
+	r.NoError(err)
+	r.Equal([]string{"module", "example.com/m"}, tokens)
+
+	_, err = modTokens(`use "./unterminated`)
+	r.Error(err)
+}
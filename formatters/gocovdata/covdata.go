@@ -0,0 +1,296 @@
+package gocovdata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// The file formats are those of the Go runtime's internal/coverage
+// package: a covmeta file describes the coverable blocks of every
+// package in a binary, and each run of the binary writes a covcounters
+// file with the counters of the functions it executed.
+var (
+	metaMagic    = []byte{0x00, 0x63, 0x76, 0x6d}
+	counterMagic = []byte{0x00, 0x63, 0x77, 0x6d}
+)
+
+const (
+	metaFileHeaderSize    = 56
+	metaPackageHeaderSize = 44
+	counterHeaderSize     = 32
+	counterFooterSize     = 16
+
+	counterFlavorRaw     = 1
+	counterFlavorULEB128 = 2
+
+	granularityPerFunc = 2
+)
+
+var counterModes = map[uint8]string{1: "set", 2: "count", 3: "atomic"}
+
+// metaFile is the decoded contents of a covmeta file.
+type metaFile struct {
+	Hash     string
+	Mode     string
+	PerFunc  bool
+	Packages []metaPackage
+}
+
+type metaPackage struct {
+	Path  string
+	Funcs []metaFunc
+}
+
+type metaFunc struct {
+	File  string
+	Units []unit
+}
+
+// unit is a block of statements sharing a counter.
+type unit struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt             int
+}
+
+// funcCounters are the counters of one function from a covcounters file.
+type funcCounters struct {
+	Package  int
+	Func     int
+	Counters []int
+}
+
+// counterFile is the decoded contents of a covcounters file.
+type counterFile struct {
+	MetaHash string
+	Funcs    []funcCounters
+}
+
+// reader reads the little endian and ULEB128 encoded values of the
+// coverage files. The first read past the end of the data sets err, and
+// every read after that returns zero.
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.b) {
+		r.err = errors.New("unexpected end of data")
+		return make([]byte, n)
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.bytes(4))
+}
+
+func (r *reader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(r.bytes(8))
+}
+
+func (r *reader) uleb128() int {
+	value := 0
+	for shift := uint(0); ; shift += 7 {
+		b := r.bytes(1)[0]
+		value |= int(b&0x7f) << shift
+		if b&0x80 == 0 || r.err != nil {
+			return value
+		}
+	}
+}
+
+// strings reads a string table: a count, then each string prefixed with
+// its length.
+func (r *reader) strings() []string {
+	n := r.uleb128()
+	strs := []string{}
+	for i := 0; i < n && r.err == nil; i++ {
+		strs = append(strs, string(r.bytes(r.uleb128())))
+	}
+	return strs
+}
+
+func (r *reader) seek(pos int) {
+	if pos < 0 || pos > len(r.b) {
+		r.err = errors.New("unexpected end of data")
+		return
+	}
+	r.pos = pos
+}
+
+func readMetaFile(path string) (metaFile, error) {
+	meta := metaFile{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return meta, errors.WithStack(err)
+	}
+
+	r := &reader{b: b}
+	if !bytes.Equal(r.bytes(4), metaMagic) {
+		return meta, errors.Errorf("%s is not a Go coverage meta-data file", path)
+	}
+	if version := r.uint32(); version != 1 {
+		return meta, errors.Errorf("%s has an unsupported meta-data version %d", path, version)
+	}
+	r.uint64() // total length
+	entries := int(r.uint64())
+	meta.Hash = hex.EncodeToString(r.bytes(16))
+	r.uint32() // string table offset
+	r.uint32() // string table length
+	mode := r.bytes(1)[0]
+	meta.PerFunc = r.bytes(1)[0] == granularityPerFunc
+	r.seek(metaFileHeaderSize)
+
+	var ok bool
+	if meta.Mode, ok = counterModes[mode]; !ok && r.err == nil {
+		return meta, errors.Errorf("%s has an unsupported counter mode %d", path, mode)
+	}
+
+	if r.err != nil || entries > len(b)/16 {
+		return meta, errors.Errorf("%s is truncated", path)
+	}
+	offsets := make([]uint64, entries)
+	for i := range offsets {
+		offsets[i] = r.uint64()
+	}
+	lengths := make([]uint64, entries)
+	for i := range lengths {
+		lengths[i] = r.uint64()
+	}
+	for i := 0; i < entries && r.err == nil; i++ {
+		if offsets[i]+lengths[i] > uint64(len(b)) {
+			return meta, errors.Errorf("%s is truncated", path)
+		}
+		pkg, err := readMetaPackage(b[offsets[i] : offsets[i]+lengths[i]])
+		if err != nil {
+			return meta, errors.Wrapf(err, "could not read package %d of %s", i, path)
+		}
+		meta.Packages = append(meta.Packages, pkg)
+	}
+	if r.err != nil {
+		return meta, errors.Wrapf(r.err, "could not read %s", path)
+	}
+	return meta, nil
+}
+
+func readMetaPackage(b []byte) (metaPackage, error) {
+	pkg := metaPackage{}
+	r := &reader{b: b}
+	r.uint32() // length
+	r.uint32() // package name
+	pathIdx := int(r.uint32())
+	r.uint32()      // module path
+	r.bytes(16 + 4) // hash, padding
+	r.uint32()      // number of files
+	numFuncs := int(r.uint32())
+	r.seek(metaPackageHeaderSize)
+	if r.err != nil || numFuncs > len(b)/4 {
+		return pkg, errors.New("unexpected end of data")
+	}
+
+	offsets := make([]int, numFuncs)
+	for i := range offsets {
+		offsets[i] = int(r.uint32())
+	}
+	strs := r.strings()
+	str := func(i int) string {
+		if i >= len(strs) {
+			r.err = errors.Errorf("string %d is not in the string table", i)
+			return ""
+		}
+		return strs[i]
+	}
+	pkg.Path = str(pathIdx)
+
+	for _, off := range offsets {
+		r.seek(off)
+		numUnits := r.uleb128()
+		r.uleb128() // function name
+		f := metaFunc{File: str(r.uleb128())}
+		for i := 0; i < numUnits && r.err == nil; i++ {
+			f.Units = append(f.Units, unit{
+				StartLine: r.uleb128(),
+				StartCol:  r.uleb128(),
+				EndLine:   r.uleb128(),
+				EndCol:    r.uleb128(),
+				NumStmt:   r.uleb128(),
+			})
+		}
+		pkg.Funcs = append(pkg.Funcs, f)
+	}
+	return pkg, r.err
+}
+
+func readCounterFile(path string) (counterFile, error) {
+	counters := counterFile{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return counters, errors.WithStack(err)
+	}
+
+	r := &reader{b: b}
+	if !bytes.Equal(r.bytes(4), counterMagic) {
+		return counters, errors.Errorf("%s is not a Go coverage counter file", path)
+	}
+	if version := r.uint32(); version != 1 {
+		return counters, errors.Errorf("%s has an unsupported counter file version %d", path, version)
+	}
+	counters.MetaHash = hex.EncodeToString(r.bytes(16))
+	flavor := r.bytes(1)[0]
+	bigEndian := r.bytes(1)[0] != 0
+	r.seek(counterHeaderSize)
+
+	var value func() int
+	switch {
+	case flavor == counterFlavorULEB128:
+		value = r.uleb128
+	case flavor == counterFlavorRaw && bigEndian:
+		value = func() int { return int(binary.BigEndian.Uint32(r.bytes(4))) }
+	case flavor == counterFlavorRaw:
+		value = func() int { return int(r.uint32()) }
+	default:
+		return counters, errors.Errorf("%s has an unsupported counter flavor %d", path, flavor)
+	}
+
+	// the file ends with a footer giving the number of segments, each of
+	// which is a header, a string table and args we don't need, and the
+	// counters, followed by a footer of its own
+	if len(b) < counterHeaderSize+counterFooterSize {
+		return counters, errors.Errorf("%s is truncated", path)
+	}
+	footer := &reader{b: b[len(b)-counterFooterSize:]}
+	if !bytes.Equal(footer.bytes(4), counterMagic) {
+		return counters, errors.Errorf("%s is truncated", path)
+	}
+	footer.uint32()
+	segments := int(footer.uint32())
+
+	for s := 0; s < segments && r.err == nil; s++ {
+		entries := int(r.uint64())
+		strTabLen := int(r.uint32())
+		argsLen := int(r.uint32())
+		r.bytes(strTabLen + argsLen)
+		for i := 0; i < entries && r.err == nil; i++ {
+			n := value()
+			f := funcCounters{Package: value(), Func: value()}
+			for j := 0; j < n && r.err == nil; j++ {
+				f.Counters = append(f.Counters, value())
+			}
+			counters.Funcs = append(counters.Funcs, f)
+		}
+		r.bytes(counterFooterSize)
+	}
+	if r.err != nil {
+		return counters, errors.Wrapf(r.err, "could not read %s", path)
+	}
+	return counters, nil
+}
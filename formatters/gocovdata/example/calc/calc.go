@@ -0,0 +1,12 @@
+package calc
+
+// Sign returns the sign of n.
+func Sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	if n > 0 {
+		return 1
+	}
+	return 0
+}
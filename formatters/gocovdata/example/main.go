@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"example.com/app/calc"
+)
+
+func main() {
+	for _, a := range os.Args[1:] {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			fmt.Println("not a number:", a)
+			continue
+		}
+		fmt.Println(calc.Sign(n))
+	}
+}
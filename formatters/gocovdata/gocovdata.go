@@ -0,0 +1,199 @@
+package gocovdata
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/codeclimate/test-reporter/formatters/gocov"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+)
+
+// Formatter reads the coverage data directories written by binaries built
+// with -cover when GOCOVERDIR is set. Each directory can hold the data of
+// several binaries and runs, and the counters of all of them are merged,
+// as "go tool covdata textfmt" does.
+type Formatter struct {
+	Paths   []string
+	Options formatters.SourceFileOptions
+	// Mode is the counter mode of the data Format read: set, count or
+	// atomic
+	Mode string
+}
+
+// Search takes the directories to read, which can also be given as a
+// comma separated list, or uses GOCOVERDIR if there are none.
+func (f *Formatter) Search(paths ...string) (string, error) {
+	if len(paths) == 0 {
+		if dir := envy.Get("GOCOVERDIR", ""); dir != "" {
+			paths = []string{dir}
+		}
+	}
+
+	f.Paths = []string{}
+	for _, arg := range paths {
+		for _, p := range strings.Split(arg, ",") {
+			logrus.Debugf("checking search path %s for gocovdata formatter", p)
+			if !HasCoverData(p) {
+				return "", errors.WithStack(errors.Errorf("could not find any Go coverage data in %s", p))
+			}
+			f.Paths = append(f.Paths, p)
+		}
+	}
+	if len(f.Paths) == 0 {
+		return "", errors.WithStack(errors.Errorf("could not find any coverage data directories for gocovdata. set GOCOVERDIR or give the directories to read"))
+	}
+	return strings.Join(f.Paths, ","), nil
+}
+
+// HasCoverData reports whether dir holds a covmeta file.
+func HasCoverData(dir string) bool {
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return false
+	}
+	metas, err := filepath.Glob(filepath.Join(dir, "covmeta.*"))
+	return err == nil && len(metas) > 0
+}
+
+type block struct {
+	file string
+	unit
+}
+
+// counts merges the counters of every run into one count per block.
+type counts struct {
+	mode   string
+	blocks map[block]int
+}
+
+func (c *counts) add(b block, n int) {
+	if c.mode == "set" {
+		if n > 0 {
+			c.blocks[b] = 1
+		} else if _, ok := c.blocks[b]; !ok {
+			c.blocks[b] = 0
+		}
+		return
+	}
+	c.blocks[b] += n
+}
+
+func (r *Formatter) Format() (formatters.Report, error) {
+	c := &counts{blocks: map[block]int{}}
+	for _, dir := range r.Paths {
+		if err := c.readDir(dir); err != nil {
+			return formatters.Report{}, err
+		}
+	}
+	r.Mode = c.mode
+
+	profiles := map[string]*cover.Profile{}
+	for b, n := range c.blocks {
+		p, ok := profiles[b.file]
+		if !ok {
+			p = &cover.Profile{FileName: b.file, Mode: c.mode}
+			profiles[b.file] = p
+		}
+		p.Blocks = append(p.Blocks, cover.ProfileBlock{
+			StartLine: b.StartLine,
+			StartCol:  b.StartCol,
+			EndLine:   b.EndLine,
+			EndCol:    b.EndCol,
+			NumStmt:   b.NumStmt,
+			Count:     n,
+		})
+	}
+
+	sorted := []*cover.Profile{}
+	for _, p := range profiles {
+		sort.Slice(p.Blocks, func(i, j int) bool {
+			bi, bj := p.Blocks[i], p.Blocks[j]
+			if bi.StartLine != bj.StartLine {
+				return bi.StartLine < bj.StartLine
+			}
+			if bi.StartCol != bj.StartCol {
+				return bi.StartCol < bj.StartCol
+			}
+			return bi.EndLine < bj.EndLine || bi.EndLine == bj.EndLine && bi.EndCol < bj.EndCol
+		})
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FileName < sorted[j].FileName })
+
+	return gocov.FormatProfiles(sorted, r.Paths[0], r.Options)
+}
+
+// readDir adds the counters of the covcounters files in dir, matching
+// each to the covmeta file of the binary that wrote it.
+func (c *counts) readDir(dir string) error {
+	metas, err := filepath.Glob(filepath.Join(dir, "covmeta.*"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, m := range metas {
+		meta, err := readMetaFile(m)
+		if err != nil {
+			return err
+		}
+		if c.mode == "" {
+			c.mode = meta.Mode
+		}
+		if c.mode != meta.Mode {
+			return errors.Errorf("can't merge %s coverage from %s with %s coverage", meta.Mode, m, c.mode)
+		}
+
+		// blocks in functions that never ran have no counters
+		for _, pkg := range meta.Packages {
+			for _, f := range pkg.Funcs {
+				for _, u := range f.Units {
+					c.add(block{file: f.File, unit: u}, 0)
+				}
+			}
+		}
+
+		counterFiles, err := filepath.Glob(filepath.Join(dir, "covcounters."+meta.Hash+".*"))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, cf := range counterFiles {
+			logrus.Debugf("reading Go coverage counters %s", cf)
+			counters, err := readCounterFile(cf)
+			if err != nil {
+				return err
+			}
+			if counters.MetaHash != meta.Hash {
+				return errors.Errorf("%s is not for the binary described by %s", cf, m)
+			}
+			if err := c.addCounters(meta, counters); err != nil {
+				return errors.Wrapf(err, "could not read %s", cf)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *counts) addCounters(meta metaFile, counters counterFile) error {
+	for _, fc := range counters.Funcs {
+		if fc.Package >= len(meta.Packages) || fc.Func >= len(meta.Packages[fc.Package].Funcs) {
+			return errors.Errorf("counters for function %d of package %d, which the meta-data doesn't have", fc.Func, fc.Package)
+		}
+		f := meta.Packages[fc.Package].Funcs[fc.Func]
+		for i, u := range f.Units {
+			n := 0
+			switch {
+			case meta.PerFunc && len(fc.Counters) > 0:
+				n = fc.Counters[0]
+			case i < len(fc.Counters):
+				n = fc.Counters[i]
+			}
+			c.add(block{file: f.File, unit: u}, n)
+		}
+	}
+	return nil
+}
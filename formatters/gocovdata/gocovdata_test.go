@@ -0,0 +1,114 @@
+package gocovdata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+// The example directories were written by the binary built from example/
+// with:
+//
+// go build -cover -coverpkg=./... -o app .
+// GOCOVERDIR=run1 ./app 5
+// GOCOVERDIR=run1 ./app x
+// GOCOVERDIR=run2 ./app -3
+//
+// and, built again with -covermode=count:
+//
+// GOCOVERDIR=count ./app 1 2 -4 0
+// GOCOVERDIR=count ./app 7
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	calc := filepath.Join("formatters", "gocovdata", "example", "calc", "calc.go")
+	main := filepath.Join("formatters", "gocovdata", "example", "main.go")
+
+	t.Run("should merge the runs of several directories", func(t *testing.T) {
+		r := require.New(t)
+
+		f := &Formatter{}
+		_, err := f.Search(filepath.Join("example", "run1"), filepath.Join("example", "run2"))
+		r.NoError(err)
+		rep, err := f.Format()
+		r.NoError(err)
+
+		r.Len(rep.SourceFiles, 2)
+		r.Equal(formatters.Coverage{
+			formatters.NullInt{}, formatters.NullInt{}, formatters.NullInt{}, formatters.NullInt{},
+			formatters.NewNullInt(1), formatters.NewNullInt(1), formatters.NewNullInt(1),
+			formatters.NewNullInt(1), formatters.NewNullInt(1), formatters.NewNullInt(1),
+			formatters.NewNullInt(0),
+		}, rep.SourceFiles[calc].Coverage)
+		r.EqualValues(100, rep.SourceFiles[main].CoveredPercent)
+	})
+
+	t.Run("should add up the counts of every run", func(t *testing.T) {
+		r := require.New(t)
+
+		f := &Formatter{}
+		_, err := f.Search(filepath.Join("example", "count"))
+		r.NoError(err)
+		rep, err := f.Format()
+		r.NoError(err)
+
+		cov := rep.SourceFiles[calc].Coverage
+		r.Len(cov, 11)
+		r.Equal(5, cov[4].Int)
+		r.Equal(1, cov[5].Int)
+		r.Equal(4, cov[7].Int)
+		r.Equal(3, cov[8].Int)
+		r.Equal(1, cov[10].Int)
+		r.Equal(0, rep.SourceFiles[main].Coverage[14].Int)
+	})
+
+	t.Run("should not merge coverage of different modes", func(t *testing.T) {
+		r := require.New(t)
+
+		f := &Formatter{}
+		_, err := f.Search(filepath.Join("example", "run1") + "," + filepath.Join("example", "count"))
+		r.NoError(err)
+		_, err = f.Format()
+		r.Error(err)
+	})
+}
+
+func Test_Search(t *testing.T) {
+	r := require.New(t)
+
+	f := &Formatter{}
+	_, err := f.Search("example")
+	r.Error(err)
+
+	p, err := f.Search(filepath.Join("example", "run1"))
+	r.NoError(err)
+	r.Equal(filepath.Join("example", "run1"), p)
+	r.Equal([]string{filepath.Join("example", "run1")}, f.Paths)
+}
+
+func Test_readMetaFile_Truncated(t *testing.T) {
+	r := require.New(t)
+
+	metas, err := filepath.Glob(filepath.Join("example", "run1", "covmeta.*"))
+	r.NoError(err)
+	r.Len(metas, 1)
+	b, err := os.ReadFile(metas[0])
+	r.NoError(err)
+
+	for _, n := range []int{0, 20, 60, len(b) - 10} {
+		path := filepath.Join(t.TempDir(), "covmeta.truncated")
+		r.NoError(os.WriteFile(path, b[:n], 0644))
+		_, err = readMetaFile(path)
+		r.Error(err, "reading %d bytes", n)
+	}
+}
@@ -0,0 +1,212 @@
+package istanbul
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+)
+
+var searchPaths = []string{"coverage/coverage-final.json"}
+
+// Formatter reads the coverage-final.json written by Istanbul's json
+// reporter, as used by nyc and Jest.
+type Formatter struct {
+	Path    string
+	Options formatters.SourceFileOptions
+}
+
+func (f *Formatter) Search(paths ...string) (string, error) {
+	paths = append(paths, searchPaths...)
+	for _, p := range paths {
+		logrus.Debugf("checking search path %s for istanbul formatter", p)
+		if _, err := os.Stat(p); err == nil {
+			f.Path = p
+			return p, nil
+		}
+	}
+
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for istanbul. search paths were: %s", strings.Join(paths, ", ")))
+}
+
+func (r Formatter) Format() (formatters.Report, error) {
+	rep, err := formatters.NewReport()
+	if err != nil {
+		return rep, err
+	}
+
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return rep, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	gitHead, _ := env.GetHead()
+
+	// the report is an object keyed by file path; decode one file at a time
+	d := json.NewDecoder(bufio.NewReader(f))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return rep, errors.Errorf("%s is not an Istanbul coverage report", r.Path)
+	}
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		key, _ := t.(string)
+
+		fc := &fileCoverage{}
+		if err := d.Decode(fc); err != nil {
+			return rep, errors.Wrapf(err, "could not read the coverage of %s", key)
+		}
+		if fc.Data != nil {
+			fc = fc.Data
+		}
+		name := fc.Path
+		if name == "" {
+			name = key
+		}
+
+		sf, err := formatters.NewSourceFile(name, gitHead, r.Options)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		sf.Coverage = fc.lineCoverage()
+		sf.Functions = fc.functions()
+		sf.Branches = fc.branches()
+		err = rep.AddSourceFile(sf)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+	}
+
+	return rep, nil
+}
+
+type position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type location struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type fnMapping struct {
+	Name string   `json:"name"`
+	Decl location `json:"decl"`
+	Loc  location `json:"loc"`
+	Line int      `json:"line"`
+}
+
+type branchMapping struct {
+	Loc       location   `json:"loc"`
+	Type      string     `json:"type"`
+	Locations []location `json:"locations"`
+	Line      int        `json:"line"`
+}
+
+// fileCoverage is the coverage of one file. Serializing a coverage map
+// rather than calling its toJSON nests it under "data".
+type fileCoverage struct {
+	Path         string                   `json:"path"`
+	StatementMap map[string]location      `json:"statementMap"`
+	FnMap        map[string]fnMapping     `json:"fnMap"`
+	BranchMap    map[string]branchMapping `json:"branchMap"`
+	S            map[string]int           `json:"s"`
+	F            map[string]int           `json:"f"`
+	B            map[string][]int         `json:"b"`
+	Data         *fileCoverage            `json:"data"`
+}
+
+// lineCoverage gives each line that statements start on the hits of the
+// least run of them, so a line is only covered once all of it has run.
+func (fc fileCoverage) lineCoverage() formatters.Coverage {
+	hits := map[int]int{}
+	last := 0
+	for id, loc := range fc.StatementMap {
+		line := loc.Start.Line
+		if line < 1 {
+			continue
+		}
+		if h, ok := hits[line]; !ok || fc.S[id] < h {
+			hits[line] = fc.S[id]
+		}
+		if line > last {
+			last = line
+		}
+	}
+
+	coverage := make(formatters.Coverage, last)
+	for line, h := range hits {
+		coverage[line-1] = formatters.NewNullInt(h)
+	}
+	return coverage
+}
+
+func (fc fileCoverage) functions() formatters.Functions {
+	functions := formatters.Functions{}
+	ids := []string{}
+	for id := range fc.FnMap {
+		ids = append(ids, id)
+	}
+	for _, id := range sortIDs(ids) {
+		fn := fc.FnMap[id]
+		start := fn.Decl.Start.Line
+		if start == 0 {
+			start = fn.Line
+		}
+		functions = append(functions, formatters.Function{
+			Name:      fn.Name,
+			StartLine: start,
+			EndLine:   fn.Loc.End.Line,
+			Hits:      fc.F[id],
+		})
+	}
+	return functions
+}
+
+func (fc fileCoverage) branches() formatters.Branches {
+	branches := formatters.Branches{}
+	ids := []string{}
+	for id := range fc.BranchMap {
+		ids = append(ids, id)
+	}
+	for _, id := range sortIDs(ids) {
+		br := fc.BranchMap[id]
+		line := br.Line
+		if line == 0 {
+			line = br.Loc.Start.Line
+		}
+		for i, taken := range fc.B[id] {
+			branches = append(branches, formatters.Branch{
+				Line:  line,
+				ID:    id + "," + strconv.Itoa(i),
+				Taken: taken,
+			})
+		}
+	}
+	return branches
+}
+
+// sortIDs sorts the keys of an Istanbul map, which are numbers, in
+// numeric order.
+func sortIDs(ids []string) []string {
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+	return ids
+}
@@ -0,0 +1,60 @@
+package istanbul
+
+import (
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./coverage-final.json"}
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+
+	sf := rep.SourceFiles["/home/ci/app/src/math.js"]
+	r.Equal(formatters.Coverage{
+		formatters.NullInt{},
+		formatters.NewNullInt(3),
+		formatters.NullInt{},
+		formatters.NullInt{},
+		formatters.NullInt{},
+		// the if ran twice, the return in it never did
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(2),
+		formatters.NullInt{},
+		formatters.NullInt{},
+		formatters.NewNullInt(1),
+	}, sf.Coverage)
+	r.Equal(4, sf.LineCounts.Total)
+	r.Equal(3, sf.LineCounts.Covered)
+
+	r.Equal(formatters.Functions{
+		{Name: "add", StartLine: 1, EndLine: 3, Hits: 3},
+		{Name: "sign", StartLine: 5, EndLine: 8, Hits: 2},
+	}, sf.Functions)
+	r.Equal(formatters.Branches{
+		{Line: 6, ID: "0,0", Taken: 0},
+		{Line: 6, ID: "0,1", Taken: 2},
+		{Line: 7, ID: "1,0", Taken: 1},
+		{Line: 7, ID: "1,1", Taken: 1},
+	}, sf.Branches)
+
+	sf = rep.SourceFiles["/home/ci/app/src/unused.js"]
+	r.Len(sf.Coverage, 2)
+	r.Equal(1, sf.Coverage[0].Int)
+	r.Equal(0, sf.Coverage[1].Int)
+	r.Equal(0, sf.FunctionCounts.Covered)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -21,7 +22,8 @@ func getSourcePaths() []string {
 }
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -49,18 +51,40 @@ func (r Formatter) Format() (formatters.Report, error) {
 	if err != nil {
 		return rep, errors.WithStack(err)
 	}
-
-	xmlJacoco := &xmlFile{}
-	err = xml.NewDecoder(fx).Decode(xmlJacoco)
-	if err != nil {
-		return rep, errors.WithStack(err)
-	}
+	defer fx.Close()
 
 	gitHead, _ := env.GetHead()
-	for _, xmlPackage := range xmlJacoco.Packages {
-		for _, xmlSF := range xmlPackage.SourceFile {
+
+	// classes are listed before the source files of their package, so
+	// their methods are collected until the package's files show up
+	packageName := ""
+	functions := map[string]formatters.Functions{}
+
+	err = formatters.StreamXML(fx, "report", func(d *xml.Decoder, t xml.Token) error {
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+
+		switch se.Name.Local {
+		case "package":
+			packageName = formatters.XMLAttr(se, "name")
+			functions = map[string]formatters.Functions{}
+		case "class":
+			xmlClass := xmlClass{}
+			if err := d.DecodeElement(&xmlClass, &se); err != nil {
+				return errors.WithStack(err)
+			}
+			sourceFileName, fns := xmlClass.functions()
+			functions[sourceFileName] = append(functions[sourceFileName], fns...)
+		case "sourcefile":
+			xmlSF := xmlSourceFile{}
+			if err := d.DecodeElement(&xmlSF, &se); err != nil {
+				return errors.WithStack(err)
+			}
+
 			num := 1
-			filepath := fmt.Sprintf("%s/%s", xmlPackage.Name, xmlSF.Name)
+			filepath := fmt.Sprintf("%s/%s", packageName, xmlSF.Name)
 			absolutePath := filepath
 			for _, sourcePath := range sourcePaths {
 				absolutePath = path.Join(sourcePath, filepath)
@@ -68,10 +92,10 @@ func (r Formatter) Format() (formatters.Report, error) {
 					break
 				}
 			}
-			sf, err := formatters.NewSourceFile(absolutePath, gitHead)
+			sf, err := formatters.NewSourceFile(absolutePath, gitHead, r.Options)
 			if err != nil {
 				logrus.Warnf("Couldn't find file for path \"%s\" from %s coverage data. Ignore if the path doesn't correspond to an existent file in your repo.", absolutePath, r.Path)
-				continue
+				return nil
 			}
 			for _, l := range xmlSF.Lines {
 				for num < l.Num {
@@ -81,13 +105,28 @@ func (r Formatter) Format() (formatters.Report, error) {
 				ni := formatters.NewNullInt(l.Hits)
 				sf.Coverage = append(sf.Coverage, ni)
 				num++
+				sf.Branches = append(sf.Branches, lineBranches(l.Num, l.CoveredBranches, l.MissedBranches)...)
 			}
-			err = rep.AddSourceFile(sf)
-			if err != nil {
-				return rep, errors.WithStack(err)
-			}
+			sf.Functions = functions[xmlSF.Name]
+			return errors.WithStack(rep.AddSourceFile(sf))
 		}
-	}
+		return nil
+	})
 
-	return rep, nil
+	return rep, err
+}
+
+// lineBranches expands JaCoCo's per-line branch counters. JaCoCo only
+// reports how many branches were covered, not how often, so covered
+// branches are recorded as taken once.
+func lineBranches(line, covered, missed int) formatters.Branches {
+	branches := formatters.Branches{}
+	for i := 0; i < covered+missed; i++ {
+		taken := 0
+		if i < covered {
+			taken = 1
+		}
+		branches = append(branches, formatters.Branch{Line: line, ID: strconv.Itoa(i), Taken: taken})
+	}
+	return branches
 }
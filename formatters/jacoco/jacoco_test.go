@@ -32,6 +32,37 @@ func Test_Parse(t *testing.T) {
 	r.False(sf.Coverage[8].Valid)
 	r.Equal(3, sf.Coverage[6].Int)
 	r.Equal(0, sf.Coverage[8].Int)
+	r.Empty(sf.Branches)
+	r.Equal(formatters.Functions{
+		{Name: "Application.<init>", StartLine: 7, Hits: 1},
+		{Name: "Application.main", StartLine: 10, Hits: 0},
+	}, sf.Functions)
+}
+
+func Test_Parse_Branches(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./branch_example.xml"}
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 1)
+
+	sf := rep.SourceFiles["com/example/Clamp.java"]
+	r.Equal(formatters.Branches{
+		{Line: 6, ID: "0", Taken: 1},
+		{Line: 6, ID: "1", Taken: 0},
+		{Line: 8, ID: "0", Taken: 1},
+		{Line: 8, ID: "1", Taken: 1},
+	}, sf.Branches)
+	r.Equal(3, sf.BranchCounts.Covered)
+	r.Equal(1, sf.BranchCounts.Missed)
+	r.InDelta(75, sf.BranchCoveredPercent, 1)
 }
 
 func Test_Parse_SourcePath(t *testing.T) {
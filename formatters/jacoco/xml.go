@@ -1,17 +1,63 @@
 package jacoco
 
-import "encoding/xml"
+import (
+	"fmt"
+	"path"
+	"strings"
 
-type xmlFile struct {
-	XMLName  xml.Name `xml:"report"`
-	Packages []struct {
-		Name       string `xml:"name,attr"`
-		SourceFile []struct {
-			Name  string `xml:"name,attr"`
-			Lines []struct {
-				Num  int `xml:"nr,attr"`
-				Hits int `xml:"ci,attr"`
-			} `xml:"line"`
-		} `xml:"sourcefile"`
-	} `xml:"package"`
+	"github.com/codeclimate/test-reporter/formatters"
+)
+
+type xmlClass struct {
+	Name           string `xml:"name,attr"`
+	SourceFileName string `xml:"sourcefilename,attr"`
+	Methods        []struct {
+		Name     string       `xml:"name,attr"`
+		Line     int          `xml:"line,attr"`
+		Counters []xmlCounter `xml:"counter"`
+	} `xml:"method"`
+}
+
+// functions returns the methods of the class and the name of the source
+// file they are defined in.
+func (c xmlClass) functions() (string, formatters.Functions) {
+	className := path.Base(c.Name)
+	sourceFileName := c.SourceFileName
+	if sourceFileName == "" {
+		// older JaCoCo reports don't name the file on the class,
+		// so assume the usual Java layout
+		sourceFileName = strings.SplitN(className, "$", 2)[0] + ".java"
+	}
+
+	fns := formatters.Functions{}
+	for _, m := range c.Methods {
+		fn := formatters.Function{
+			Name:      fmt.Sprintf("%s.%s", className, m.Name),
+			StartLine: m.Line,
+		}
+		// JaCoCo doesn't count calls, only whether the method ran
+		for _, counter := range m.Counters {
+			if counter.Type == "METHOD" {
+				fn.Hits = counter.Covered
+			}
+		}
+		fns = append(fns, fn)
+	}
+	return sourceFileName, fns
+}
+
+type xmlSourceFile struct {
+	Name  string `xml:"name,attr"`
+	Lines []struct {
+		Num             int `xml:"nr,attr"`
+		Hits            int `xml:"ci,attr"`
+		MissedBranches  int `xml:"mb,attr"`
+		CoveredBranches int `xml:"cb,attr"`
+	} `xml:"line"`
+}
+
+type xmlCounter struct {
+	Type    string `xml:"type,attr"`
+	Missed  int    `xml:"missed,attr"`
+	Covered int    `xml:"covered,attr"`
 }
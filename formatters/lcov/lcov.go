@@ -1,8 +1,8 @@
 package lcov
 
 import (
+	"bufio"
 	"bytes"
-	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -15,8 +15,12 @@ import (
 
 var searchPaths = []string{"coverage/lcov.info"}
 
+// the longest line we expect, mangled C++ function names can be long
+const maxLineLength = 1024 * 1024
+
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -38,23 +42,55 @@ func (r Formatter) Format() (formatters.Report, error) {
 		return rep, err
 	}
 
-	b, err := ioutil.ReadFile(r.Path)
+	f, err := os.Open(r.Path)
 	if err != nil {
 		return rep, errors.WithStack(err)
 	}
+	defer f.Close()
 
 	var gitHead, _ = env.GetHead()
 
 	var sf formatters.SourceFile
 	curLine := 1
+	functions := map[string]int{}
 
-	for _, line := range bytes.Split(b, []byte("\n")) {
+	// read a line at a time, lcov files for large C/C++ projects can be
+	// several gigabytes
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		line := scanner.Bytes()
 		if bytes.HasPrefix(line, []byte("SF:")) {
 			name := string(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("SF:"))))
-			sf, err = formatters.NewSourceFile(name, gitHead)
+			sf, err = formatters.NewSourceFile(name, gitHead, r.Options)
+			if err != nil {
+				return rep, errors.WithStack(err)
+			}
+			functions = map[string]int{}
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("FN:")) {
+			fn, err := parseFunction(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("FN:"))))
+			if err != nil {
+				return rep, errors.WithStack(err)
+			}
+			functions[fn.Name] = len(sf.Functions)
+			sf.Functions = append(sf.Functions, fn)
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("FNDA:")) {
+			// FNDA:<hits>,<name>
+			fnInfo := bytes.SplitN(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("FNDA:"))), []byte(","), 2)
+			if len(fnInfo) != 2 {
+				return rep, errors.Errorf("invalid function record %q in %s", line, r.Path)
+			}
+			hits, err := strconv.Atoi(string(fnInfo[0]))
 			if err != nil {
 				return rep, errors.WithStack(err)
 			}
+			if i, ok := functions[string(fnInfo[1])]; ok {
+				sf.Functions[i].Hits += hits
+			}
 			continue
 		}
 		if bytes.HasPrefix(line, []byte("DA:")) {
@@ -75,6 +111,31 @@ func (r Formatter) Format() (formatters.Report, error) {
 			curLine++
 			continue
 		}
+		if bytes.HasPrefix(line, []byte("BRDA:")) {
+			// BRDA:<line>,<block>,<branch>,<taken>
+			branchInfo := bytes.Split(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("BRDA:"))), []byte(","))
+			if len(branchInfo) != 4 {
+				return rep, errors.Errorf("invalid branch record %q in %s", line, r.Path)
+			}
+			ln, err := strconv.Atoi(string(branchInfo[0]))
+			if err != nil {
+				return rep, errors.WithStack(err)
+			}
+			// a "-" means the block containing the branch was never executed
+			taken := 0
+			if string(branchInfo[3]) != "-" {
+				taken, err = strconv.Atoi(string(branchInfo[3]))
+				if err != nil {
+					return rep, errors.WithStack(err)
+				}
+			}
+			sf.Branches = append(sf.Branches, formatters.Branch{
+				Line:  ln,
+				ID:    string(branchInfo[1]) + "," + string(branchInfo[2]),
+				Taken: taken,
+			})
+			continue
+		}
 		if bytes.HasPrefix(line, []byte("end_of_record")) {
 			err = rep.AddSourceFile(sf)
 			if err != nil {
@@ -84,6 +145,35 @@ func (r Formatter) Format() (formatters.Report, error) {
 			continue
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return rep, errors.WithStack(err)
+	}
 
 	return rep, nil
 }
+
+// parseFunction reads both the "<line>,<name>" form of the FN record and
+// the "<start>,<end>,<name>" form written by lcov 2.
+func parseFunction(record []byte) (formatters.Function, error) {
+	fn := formatters.Function{}
+	fnInfo := bytes.SplitN(record, []byte(","), 3)
+	if len(fnInfo) < 2 {
+		return fn, errors.Errorf("invalid function record %q", record)
+	}
+
+	var err error
+	fn.StartLine, err = strconv.Atoi(string(fnInfo[0]))
+	if err != nil {
+		return fn, errors.WithStack(err)
+	}
+
+	if len(fnInfo) == 3 {
+		if end, err := strconv.Atoi(string(fnInfo[1])); err == nil {
+			fn.EndLine = end
+			fn.Name = string(fnInfo[2])
+			return fn, nil
+		}
+	}
+	fn.Name = string(record[len(fnInfo[0])+1:])
+	return fn, nil
+}
@@ -11,6 +11,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,6 +56,33 @@ func Test_Format(t *testing.T) {
 	r.Equal(47, lc.Covered)
 	r.Equal(5, lc.Missed)
 	r.Equal(52, lc.Total)
+
+	r.Len(sf.Branches, 16)
+	r.Equal(formatters.Branch{Line: 11, ID: "1,0", Taken: 1}, sf.Branches[0])
+	bc := rep.BranchCounts
+	r.Equal(9, bc.Covered)
+	r.Equal(7, bc.Missed)
+	r.Equal(16, bc.Total)
+	r.InDelta(56.25, rep.BranchCoveredPercent, 1)
+
+	r.Len(sf.Functions, 10)
+	r.Equal(formatters.Function{Name: "(anonymous_2)", StartLine: 14, Hits: 5}, sf.Functions[1])
+	r.Equal(10, rep.FunctionCounts.Covered)
+}
+
+func Test_parseFunction(t *testing.T) {
+	r := require.New(t)
+
+	fn, err := parseFunction([]byte("10,Formatter"))
+	r.NoError(err)
+	r.Equal(formatters.Function{Name: "Formatter", StartLine: 10}, fn)
+
+	fn, err = parseFunction([]byte("10,22,std::map<int, int>::at"))
+	r.NoError(err)
+	r.Equal(formatters.Function{Name: "std::map<int, int>::at", StartLine: 10, EndLine: 22}, fn)
+
+	_, err = parseFunction([]byte("Formatter"))
+	r.Error(err)
 }
 
 func Benchmark_Format(b *testing.B) {
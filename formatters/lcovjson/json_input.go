@@ -3,6 +3,8 @@ package lcovjson
 import (
 	"encoding/json"
 	"errors"
+
+	"github.com/codeclimate/test-reporter/formatters"
 )
 
 type segment struct {
@@ -11,6 +13,7 @@ type segment struct {
 	Count int
 	HasCount bool
 	IsRegionEntry bool
+	IsGapRegion bool
 }
 
 func (segment *segment) UnmarshalJSON(data []byte) error {
@@ -18,6 +21,9 @@ func (segment *segment) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &array); err != nil {
 		return err
 	}
+	if len(array) < 5 {
+		return errors.New("invalid segment")
+	}
 
 	if n, ok := array[0].(float64); ok {
 		segment.Line = int(n)
@@ -49,6 +55,15 @@ func (segment *segment) UnmarshalJSON(data []byte) error {
 		return errors.New("invalid IsRegionEntry")
 	}
 
+	// exports from before LLVM 7 have no gap regions
+	if len(array) > 5 {
+		if b, ok := array[5].(bool); ok {
+			segment.IsGapRegion = b
+		} else {
+			return errors.New("invalid IsGapRegion")
+		}
+	}
+
 	return nil
 }
 
@@ -146,6 +161,29 @@ type function struct {
 	Regions []region `json:"regions"`
 }
 
+// toFunction describes the function by the regions that belong to the
+// file it is defined in, which llvm-cov always lists first.
+func (f function) toFunction() formatters.Function {
+	fn := formatters.Function{
+		Name:      f.Name,
+		StartLine: f.Regions[0].LineStart,
+		EndLine:   f.Regions[0].LineEnd,
+		Hits:      f.Count,
+	}
+	for _, r := range f.Regions {
+		if r.FileID != 0 {
+			continue
+		}
+		if r.LineStart < fn.StartLine {
+			fn.StartLine = r.LineStart
+		}
+		if r.LineEnd > fn.EndLine {
+			fn.EndLine = r.LineEnd
+		}
+	}
+	return fn
+}
+
 type lcovJsonFile struct {
 	Data []struct {
 		Files []sourceFile `json:"files"`
@@ -3,6 +3,7 @@ package lcovjson
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -11,12 +12,17 @@ import (
 	"github.com/pkg/errors"
 )
 
+// swift test --enable-code-coverage exports the coverage of a package
+// here, and llvm-cov export is usually redirected to coverage.json
+var searchPaths = []string{".build/debug/codecov/*.json", "coverage.json"}
+
+// Formatter reads the JSON written by llvm-cov export.
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
-	paths = append(paths)
 	for _, p := range paths {
 		logrus.Debugf("checking search path %s for lcov-json formatter", p)
 		if _, err := os.Stat(p); err == nil {
@@ -24,8 +30,16 @@ func (f *Formatter) Search(paths ...string) (string, error) {
 			return p, nil
 		}
 	}
+	for _, p := range searchPaths {
+		logrus.Debugf("checking search path %s for lcov-json formatter", p)
+		matches, err := filepath.Glob(p)
+		if err == nil && len(matches) > 0 {
+			f.Path = matches[0]
+			return f.Path, nil
+		}
+	}
 
-	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for lcov-json. search paths were: %s", strings.Join(paths, ", ")))
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for lcov-json. search paths were: %s", strings.Join(append(paths, searchPaths...), ", ")))
 }
 
 func (r Formatter) Format() (formatters.Report, error) {
@@ -48,47 +62,24 @@ func (r Formatter) Format() (formatters.Report, error) {
 	gitHead, _ := env.GetHead()
 	for _, target := range covFile.Data {
 		report.CoveredPercent = target.Totals.Lines.Percent
-		regionsByFilename := make(map[string][]region)
+		functionsByFilename := make(map[string]formatters.Functions)
 
 		for _, function := range target.Functions {
-			for _, filename := range function.Filenames {
-				regionsByFilename[filename] = append(regionsByFilename[filename], function.Regions...)
+			if len(function.Filenames) > 0 && len(function.Regions) > 0 {
+				filename := function.Filenames[0]
+				functionsByFilename[filename] = append(functionsByFilename[filename], function.toFunction())
 			}
 		}
 
-		for filename, regions := range regionsByFilename {
-			sourceFile, err := formatters.NewSourceFile(filename, gitHead)
+		for _, file := range target.Files {
+			sourceFile, err := formatters.NewSourceFile(file.Filename, gitHead, r.Options)
 			if err != nil {
-				logrus.Warnf("Couldn't find file at path \"%s\" from %s coverage data. Ignore if the path doesn't correspond to an existent file in your repo.", filename, r.Path)
+				logrus.Warnf("Couldn't find file at path \"%s\" from %s coverage data. Ignore if the path doesn't correspond to an existent file in your repo.", file.Filename, r.Path)
 				continue
 			}
 
-			coverage := make(map[int]formatters.NullInt)
-			lastLine := 1
-
-			for _, region := range regions {
-				for line := region.LineStart; line <= region.LineEnd; line++ {
-					coverage[line] = formatters.NewNullInt(1)
-
-					if region.ExecutionCount == 0 {
-						coverage[line] = formatters.NewNullInt(0)
-					}
-
-					if line > lastLine {
-						lastLine = line
-					}
-				}
-			}
-
-			for line := 0; line <= lastLine; line++ {
-				executionCount, isPresent := coverage[line]
-
-				if isPresent {
-					sourceFile.Coverage = append(sourceFile.Coverage, executionCount)
-				} else {
-					sourceFile.Coverage = append(sourceFile.Coverage, formatters.NullInt{})
-				}
-			}
+			sourceFile.Coverage = lineCoverage(file.Segments)
+			sourceFile.Functions = functionsByFilename[file.Filename]
 
 			err = report.AddSourceFile(sourceFile)
 			if err != nil {
@@ -6,6 +6,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +34,9 @@ func Test_Format(t *testing.T) {
 	r.Equal(sfLc.Covered, 6)
 	r.Equal(sfLc.Missed, 3)
 	r.Equal(sfLc.Total, 9)
+	r.Equal(formatters.Function{Name: "$s7Codecov4UserV8fullNameSSvg", StartLine: 19, EndLine: 21, Hits: 0}, sf.Functions[1])
+	r.Equal(1, sf.FunctionCounts.Covered)
+	r.Equal(2, sf.FunctionCounts.Total)
 
 	sf = rep.SourceFiles["/Users/paulo/Development/GitHub/paulofaria/Codecov/Tests/CodecovTests/CodecovTests.swift"]
 	r.InDelta(sf.CoveredPercent, 100, 1)
@@ -41,3 +45,35 @@ func Test_Format(t *testing.T) {
 	r.Equal(sfLc.Missed, 0)
 	r.Equal(sfLc.Total, 18)
 }
+
+func Test_lineCoverage(t *testing.T) {
+	r := require.New(t)
+
+	segments := []segment{
+		{Line: 1, Column: 14, Count: 5, HasCount: true, IsRegionEntry: true},
+		// a region that ran less often starts on a line
+		{Line: 3, Column: 12, Count: 2, HasCount: true, IsRegionEntry: true},
+		{Line: 3, Column: 20, Count: 5, HasCount: true},
+		{Line: 4, Column: 5, Count: 0, HasCount: true, IsRegionEntry: true},
+		{Line: 4, Column: 20, Count: 0, HasCount: true},
+		// gap regions don't start lines
+		{Line: 5, Column: 3, Count: 9, HasCount: true, IsRegionEntry: true, IsGapRegion: true},
+		// a skipped region
+		{Line: 6, Column: 1, IsRegionEntry: true},
+		{Line: 7, Column: 1},
+		{Line: 8, Column: 3, Count: 7, HasCount: true, IsRegionEntry: true},
+		{Line: 9, Column: 2},
+	}
+	r.Equal(formatters.Coverage{
+		formatters.NewNullInt(5),
+		// wrapped from line 1
+		formatters.NewNullInt(5),
+		formatters.NewNullInt(5),
+		formatters.NewNullInt(5),
+		formatters.NewNullInt(0),
+		formatters.NullInt{},
+		formatters.NullInt{},
+		formatters.NewNullInt(7),
+		formatters.NewNullInt(7),
+	}, lineCoverage(segments))
+}
@@ -0,0 +1,68 @@
+package lcovjson
+
+import "github.com/codeclimate/test-reporter/formatters"
+
+// lineCoverage computes the execution count of each line from the segments
+// of a file the way llvm-cov show does. A line is mapped if a region
+// starts on it or if a region with a count wraps onto it from the lines
+// before, unless it starts a skipped region, and it gets the largest count
+// of the regions starting on it and the one wrapping onto it. Gap regions,
+// such as the space between an if and its else, don't count as starting
+// on a line.
+func lineCoverage(segments []segment) formatters.Coverage {
+	if len(segments) == 0 {
+		return formatters.Coverage{}
+	}
+
+	coverage := make(formatters.Coverage, segments[len(segments)-1].Line)
+	var wrapped *segment
+	for i, line := 0, segments[0].Line; i < len(segments); line++ {
+		start := i
+		for i < len(segments) && segments[i].Line == line {
+			i++
+		}
+		lineSegments := segments[start:i]
+
+		if count, mapped := lineCount(lineSegments, wrapped); mapped && line > 0 {
+			coverage[line-1] = formatters.NewNullInt(count)
+		}
+		if len(lineSegments) > 0 {
+			wrapped = &lineSegments[len(lineSegments)-1]
+		}
+	}
+	return coverage
+}
+
+func lineCount(lineSegments []segment, wrapped *segment) (int, bool) {
+	isStartOfRegion := func(s segment) bool {
+		return !s.IsGapRegion && s.HasCount && s.IsRegionEntry
+	}
+
+	regions := 0
+	entry := false
+	for _, s := range lineSegments {
+		if isStartOfRegion(s) {
+			regions++
+		}
+		if s.IsRegionEntry && s.HasCount {
+			entry = true
+		}
+	}
+	skipped := len(lineSegments) > 0 && !lineSegments[0].HasCount && lineSegments[0].IsRegionEntry
+
+	mapped := !skipped && (wrapped != nil && wrapped.HasCount || regions > 0) || entry
+	if !mapped {
+		return 0, false
+	}
+
+	count := 0
+	if wrapped != nil {
+		count = wrapped.Count
+	}
+	for _, s := range lineSegments {
+		if isStartOfRegion(s) && s.Count > count {
+			count = s.Count
+		}
+	}
+	return count, true
+}
@@ -0,0 +1,216 @@
+package opencover
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+var searchPaths = []string{"coverage.opencover.xml", "opencover.xml"}
+
+// the endings of the file names the .NET build tools generate
+var generatedSuffixes = []string{
+	".g.cs", ".g.i.cs", ".designer.cs", ".generated.cs",
+	".g.vb", ".designer.vb",
+	"assemblyinfo.cs", "assemblyattributes.cs", "assemblyinfo.vb",
+}
+
+// Formatter is the exported struct to be used on format-coverage.go
+type Formatter struct {
+	Path    string
+	Options formatters.SourceFileOptions
+}
+
+// Search looks for the OpenCover test report file in default paths or provided ones.
+func (f *Formatter) Search(paths ...string) (string, error) {
+	paths = append(paths, searchPaths...)
+	for _, p := range paths {
+		logrus.Debugf("checking search path %s for opencover formatter", p)
+		if _, err := os.Stat(p); err == nil {
+			f.Path = p
+			return p, nil
+		}
+	}
+
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for opencover. search paths were: %s", strings.Join(paths, ", ")))
+}
+
+// Format transforms the OpenCover XML written by OpenCover or coverlet into
+// a CC readable report. A file that is part of several modules gets the
+// hits of all of them.
+func (f Formatter) Format() (formatters.Report, error) {
+	rep, err := formatters.NewReport()
+	if err != nil {
+		return rep, err
+	}
+
+	fx, err := os.Open(f.Path)
+	if err != nil {
+		return rep, errors.WithStack(err)
+	}
+	defer fx.Close()
+
+	gitHead, _ := env.GetHead()
+
+	err = formatters.StreamXML(fx, "CoverageSession", func(d *xml.Decoder, t xml.Token) error {
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "Module" {
+			return nil
+		}
+
+		m := xmlModule{}
+		if err := d.DecodeElement(&m, &se); err != nil {
+			return errors.WithStack(err)
+		}
+		if m.SkippedDueTo != "" {
+			logrus.Debugf("skipping module %s, it was not covered: %s", m.Name, m.SkippedDueTo)
+			return nil
+		}
+
+		files, err := m.sourceFiles(gitHead, f.Options)
+		if err != nil {
+			return err
+		}
+		for _, sf := range files {
+			if err := rep.AddSourceFile(sf); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	return rep, nil
+}
+
+type fileCoverage struct {
+	hits      map[int]int
+	last      int
+	functions formatters.Functions
+	branches  formatters.Branches
+}
+
+// addLine records the hits of a line. A line with several sequence points
+// gets the hits of the least visited of them.
+func (fc *fileCoverage) addLine(line int, hits int) {
+	if line < 1 {
+		return
+	}
+	if h, ok := fc.hits[line]; !ok || hits < h {
+		fc.hits[line] = hits
+	}
+	if line > fc.last {
+		fc.last = line
+	}
+}
+
+func (m xmlModule) sourceFiles(gitHead *object.Commit, opts formatters.SourceFileOptions) ([]formatters.SourceFile, error) {
+	coverage := map[int]*fileCoverage{}
+	file := func(uid int) *fileCoverage {
+		fc, ok := coverage[uid]
+		if !ok {
+			fc = &fileCoverage{hits: map[int]int{}}
+			coverage[uid] = fc
+		}
+		return fc
+	}
+
+	for _, c := range m.Classes {
+		for _, method := range c.Methods {
+			if len(method.SequencePoints) == 0 {
+				continue
+			}
+
+			methodFile := method.FileRef.UID
+			if methodFile == 0 {
+				methodFile = method.SequencePoints[0].FileID
+			}
+
+			fn := formatters.Function{Name: method.Name, Hits: method.SequencePoints[0].VisitCount}
+			if method.MethodPoint != nil {
+				fn.Hits = method.MethodPoint.VisitCount
+			}
+			for _, sp := range method.SequencePoints {
+				uid := sp.FileID
+				if uid == 0 {
+					uid = methodFile
+				}
+				fc := file(uid)
+				end := sp.EndLine
+				if end < sp.StartLine {
+					end = sp.StartLine
+				}
+				for l := sp.StartLine; l <= end; l++ {
+					fc.addLine(l, sp.VisitCount)
+				}
+				if fn.StartLine == 0 || sp.StartLine < fn.StartLine {
+					fn.StartLine = sp.StartLine
+				}
+				if end > fn.EndLine {
+					fn.EndLine = end
+				}
+			}
+
+			fc := file(methodFile)
+			fc.functions = append(fc.functions, fn)
+
+			for _, bp := range method.BranchPoints {
+				uid := bp.FileID
+				if uid == 0 {
+					uid = methodFile
+				}
+				bf := file(uid)
+				bf.branches = append(bf.branches, formatters.Branch{
+					Line:  bp.Line,
+					ID:    fmt.Sprintf("%d,%d", bp.Offset, bp.Path),
+					Taken: bp.VisitCount,
+				})
+			}
+		}
+	}
+
+	files := []formatters.SourceFile{}
+	for _, f := range m.Files {
+		fc, ok := coverage[f.UID]
+		if !ok {
+			continue
+		}
+		if isGenerated(f.FullPath) {
+			logrus.Debugf("skipping generated file %s", f.FullPath)
+			continue
+		}
+
+		sf, err := formatters.NewSourceFile(f.FullPath, gitHead, opts)
+		if err != nil {
+			return files, errors.WithStack(err)
+		}
+		sf.Coverage = make(formatters.Coverage, fc.last)
+		for l, h := range fc.hits {
+			sf.Coverage[l-1] = formatters.NewNullInt(h)
+		}
+		sf.Functions = fc.functions
+		sf.Branches = fc.branches
+		files = append(files, sf)
+	}
+	return files, nil
+}
+
+func isGenerated(name string) bool {
+	name = strings.ToLower(path.Base(strings.Replace(name, `\`, "/", -1)))
+	for _, s := range generatedSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
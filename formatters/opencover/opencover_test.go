@@ -0,0 +1,59 @@
+package opencover
+
+import (
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./example.xml"}
+	rep, err := f.Format()
+	r.NoError(err)
+	// the generated AssemblyInfo file, Report.cs, which has no sequence
+	// points, and the skipped test module aren't in the report
+	r.Len(rep.SourceFiles, 2)
+
+	sf := rep.SourceFiles["/home/ci/src/Calculator/Calculator.cs"]
+	r.Len(sf.Coverage, 18)
+	r.Equal(formatters.NullInt{}, sf.Coverage[6])
+	r.Equal(formatters.NewNullInt(4), sf.Coverage[7])
+	r.Equal(formatters.NewNullInt(4), sf.Coverage[9])
+	// line 16 has a sequence point that ran twice and one that never did
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[15])
+	r.Equal(formatters.NewNullInt(2), sf.Coverage[16])
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[17])
+	r.Equal(9, sf.LineCounts.Total)
+	r.Equal(7, sf.LineCounts.Covered)
+
+	r.Equal(formatters.Functions{
+		{Name: "System.Int32 Calculator.Calculator::Add(System.Int32,System.Int32)", StartLine: 8, EndLine: 11, Hits: 4},
+		{Name: "System.Int32 Calculator.Calculator::Divide(System.Int32,System.Int32)", StartLine: 14, EndLine: 18, Hits: 2},
+	}, sf.Functions)
+	r.Equal(formatters.Branches{
+		{Line: 16, ID: "12,0", Taken: 0},
+		{Line: 16, ID: "12,1", Taken: 2},
+		{Line: 17, ID: "40,0", Taken: 1},
+		{Line: 17, ID: "40,1", Taken: 1},
+	}, sf.Branches)
+
+	// Guard.cs is part of both modules
+	sf = rep.SourceFiles["/home/ci/src/Shared/Guard.cs"]
+	r.Equal(formatters.NewNullInt(3), sf.Coverage[5])
+	r.Equal(formatters.NewNullInt(1), sf.Coverage[6])
+	r.Equal(formatters.NewNullInt(2), sf.Coverage[7])
+	r.Equal(3, sf.LineCounts.Covered)
+	r.Equal(0, sf.LineCounts.Missed)
+}
@@ -0,0 +1,39 @@
+package opencover
+
+type xmlModule struct {
+	SkippedDueTo string    `xml:"skippedDueTo,attr"`
+	Name         string    `xml:"ModuleName"`
+	Files        []xmlFile `xml:"Files>File"`
+	Classes      []struct {
+		Methods []xmlMethod `xml:"Methods>Method"`
+	} `xml:"Classes>Class"`
+}
+
+type xmlFile struct {
+	UID      int    `xml:"uid,attr"`
+	FullPath string `xml:"fullPath,attr"`
+}
+
+type xmlMethod struct {
+	Visited bool   `xml:"visited,attr"`
+	Name    string `xml:"Name"`
+	FileRef struct {
+		UID int `xml:"uid,attr"`
+	} `xml:"FileRef"`
+	SequencePoints []xmlSequencePoint `xml:"SequencePoints>SequencePoint"`
+	BranchPoints   []struct {
+		VisitCount int `xml:"vc,attr"`
+		Line       int `xml:"sl,attr"`
+		Offset     int `xml:"offset,attr"`
+		Path       int `xml:"path,attr"`
+		FileID     int `xml:"fileid,attr"`
+	} `xml:"BranchPoints>BranchPoint"`
+	MethodPoint *xmlSequencePoint `xml:"MethodPoint"`
+}
+
+type xmlSequencePoint struct {
+	VisitCount int `xml:"vc,attr"`
+	StartLine  int `xml:"sl,attr"`
+	EndLine    int `xml:"el,attr"`
+	FileID     int `xml:"fileid,attr"`
+}
@@ -0,0 +1,101 @@
+package formatters
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// PathMapRule rewrites the source file paths matching Pattern, using
+// Replacement the way regexp.ReplaceAllString does, so "$1" refers to
+// the first group of the pattern.
+type PathMapRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// ParsePathMapRule parses a rule written as "regex=>replacement".
+func ParsePathMapRule(s string) (PathMapRule, error) {
+	parts := strings.SplitN(s, "=>", 2)
+	if len(parts) != 2 {
+		return PathMapRule{}, errors.Errorf("invalid path map rule %q, expected 'regex=>replacement'", s)
+	}
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return PathMapRule{}, errors.Wrapf(err, "invalid path map rule %q", s)
+	}
+	return PathMapRule{Pattern: re, Replacement: parts[1]}, nil
+}
+
+func (r PathMapRule) String() string {
+	return r.Pattern.String() + "=>" + r.Replacement
+}
+
+// PathMap is an ordered list of rules applied to every source file path.
+// Each rule sees the path as rewritten by the rules before it.
+type PathMap struct {
+	Rules []PathMapRule
+	// DropUnmatched leaves out of the report the files no rule matched
+	DropUnmatched bool
+}
+
+// Apply rewrites the path with every rule that matches it, and reports
+// whether any did.
+func (m PathMap) Apply(name string) (string, bool) {
+	matched := false
+	for _, rule := range m.Rules {
+		if !rule.Pattern.MatchString(name) {
+			continue
+		}
+		matched = true
+		mapped := rule.Pattern.ReplaceAllString(name, rule.Replacement)
+		logrus.Debugf("path map rule %s rewrote %s to %s", rule, name, mapped)
+		name = mapped
+	}
+	return name, matched
+}
+
+// ParsePathMap parses the rules given on the command line, followed by
+// the rules in the files, which have one rule per line. Blank lines and
+// lines starting with "#" are skipped.
+func ParsePathMap(rules []string, files ...string) (PathMap, error) {
+	m := PathMap{}
+	for _, file := range files {
+		fileRules, err := readPathMapFile(file)
+		if err != nil {
+			return m, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	for _, s := range rules {
+		rule, err := ParsePathMapRule(s)
+		if err != nil {
+			return m, err
+		}
+		m.Rules = append(m.Rules, rule)
+	}
+	return m, nil
+}
+
+func readPathMapFile(path string) ([]string, error) {
+	rules := []string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	return rules, errors.WithStack(scanner.Err())
+}
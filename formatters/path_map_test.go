@@ -0,0 +1,62 @@
+package formatters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParsePathMapRule(t *testing.T) {
+	r := require.New(t)
+
+	rule, err := ParsePathMapRule(`^/app/(.+)=>src/$1`)
+	r.NoError(err)
+	r.Equal("^/app/(.+)", rule.Pattern.String())
+	r.Equal("src/$1", rule.Replacement)
+	r.Equal(`^/app/(.+)=>src/$1`, rule.String())
+
+	_, err = ParsePathMapRule("^/app/")
+	r.Error(err)
+
+	_, err = ParsePathMapRule("(=>x")
+	r.Error(err)
+}
+
+func Test_PathMap_Apply(t *testing.T) {
+	r := require.New(t)
+
+	m, err := ParsePathMap([]string{`^/app/=>`, `^bazel-out/[^/]+/bin/=>`, `\.pb\.go$=>.go`})
+	r.NoError(err)
+
+	name, ok := m.Apply("/app/lib/foo.rb")
+	r.True(ok)
+	r.Equal("lib/foo.rb", name)
+
+	// later rules see the path rewritten by earlier ones
+	name, ok = m.Apply("bazel-out/k8-fastbuild/bin/pkg/api.pb.go")
+	r.True(ok)
+	r.Equal("pkg/api.go", name)
+
+	name, ok = m.Apply("lib/bar.rb")
+	r.False(ok)
+	r.Equal("lib/bar.rb", name)
+}
+
+func Test_ParsePathMap_File(t *testing.T) {
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "path-map")
+	r.NoError(os.WriteFile(path, []byte("# docker\n^/app/=>\n\n  ^lib/=>src/  \n"), 0644))
+
+	m, err := ParsePathMap([]string{`^/build/=>`}, path)
+	r.NoError(err)
+	r.Len(m.Rules, 3)
+	r.Equal("^/build/", m.Rules[0].Pattern.String())
+	r.Equal("^lib/", m.Rules[2].Pattern.String())
+	r.Equal("src/", m.Rules[2].Replacement)
+
+	_, err = ParsePathMap(nil, filepath.Join(t.TempDir(), "missing"))
+	r.Error(err)
+}
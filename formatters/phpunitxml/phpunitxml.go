@@ -0,0 +1,220 @@
+package phpunitxml
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+)
+
+var searchPaths = []string{"build/coverage-xml", "build/logs/coverage-xml", "coverage-xml"}
+
+// Formatter reads the directory written by phpunit --coverage-xml: an
+// index.xml listing the source files, and an XML file for each of them
+// naming the tests that covered each line.
+type Formatter struct {
+	Path    string
+	Options formatters.SourceFileOptions
+
+	// KeepTests keeps the names of the tests that covered each line in
+	// the CoveredBy of the source files
+	KeepTests bool
+}
+
+// Search takes the report directory, or its index.xml.
+func (f *Formatter) Search(paths ...string) (string, error) {
+	paths = append(paths, searchPaths...)
+	for _, p := range paths {
+		logrus.Debugf("checking search path %s for phpunit-xml formatter", p)
+		if filepath.Base(p) == "index.xml" {
+			p = filepath.Dir(p)
+		}
+		if _, err := os.Stat(filepath.Join(p, "index.xml")); err == nil {
+			f.Path = p
+			return p, nil
+		}
+	}
+
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for phpunit-xml. search paths were: %s", strings.Join(paths, ", ")))
+}
+
+// Format gives each line a hit for every test that covered it, and the
+// executable lines no test covered, which PHPUnit leaves out, none.
+func (f *Formatter) Format() (formatters.Report, error) {
+	rep, err := formatters.NewReport()
+	if err != nil {
+		return rep, err
+	}
+
+	index := xmlIndex{}
+	if err := readXML(filepath.Join(f.Path, "index.xml"), &index); err != nil {
+		return rep, err
+	}
+	gitHead, _ := env.GetHead()
+	for _, href := range index.Project.Directory.hrefs() {
+		xf := xmlFile{}
+		if err := readXML(filepath.Join(f.Path, filepath.FromSlash(href)), &xf); err != nil {
+			return rep, err
+		}
+
+		name := filepath.Join(index.Project.Source, filepath.FromSlash(xf.File.Path), xf.File.Name)
+		sf, err := formatters.NewSourceFile(name, gitHead, f.Options)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+
+		tests := map[int][]string{}
+		last := 0
+		for _, l := range xf.File.Lines {
+			if l.Num < 1 {
+				continue
+			}
+			for _, c := range l.Covered {
+				tests[l.Num] = append(tests[l.Num], c.By)
+			}
+			if _, ok := tests[l.Num]; !ok {
+				tests[l.Num] = []string{}
+			}
+			if l.Num > last {
+				last = l.Num
+			}
+		}
+		missed, unplaced := xf.missed(tests)
+		for _, l := range missed {
+			if l > last {
+				last = l
+			}
+		}
+		sf.Coverage = make(formatters.Coverage, last)
+		for l, t := range tests {
+			sf.Coverage[l-1] = formatters.NewNullInt(len(t))
+		}
+		for _, l := range missed {
+			sf.Coverage[l-1] = formatters.NewNullInt(0)
+		}
+		if f.KeepTests {
+			sf.CoveredBy = tests
+		}
+
+		err = rep.AddSourceFile(sf)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		// files the filter left out don't matter
+		if _, ok := rep.SourceFiles[sf.Name]; ok && unplaced > 0 {
+			logrus.Warnf("%s doesn't say which %d of the executable lines of %s no test covered, so they aren't counted", href, unplaced, name)
+		}
+	}
+
+	return rep, nil
+}
+
+// nonCode are the tokens of lines without code.
+var nonCode = map[string]bool{
+	"T_WHITESPACE":  true,
+	"T_COMMENT":     true,
+	"T_DOC_COMMENT": true,
+	"T_OPEN_TAG":    true,
+	"T_CLOSE_TAG":   true,
+	"T_OPEN_CURLY":  true,
+	"T_CLOSE_CURLY": true,
+}
+
+// missed places the executable lines no test covered, which PHPUnit leaves
+// out. Each method says how many of its lines ran, so those that didn't are
+// taken from the lines of its body no test covered, in order, and what's
+// left of the file's total from the lines outside of the methods. The
+// source in the file tells which lines have code; without it any line of a
+// method's body will do, and what's left can't be placed.
+func (xf xmlFile) missed(covered map[int][]string) ([]int, int) {
+	code := map[int]bool{}
+	for _, l := range xf.File.Source {
+		for _, t := range l.Tokens {
+			if !nonCode[t.Name] {
+				code[l.Num] = true
+				break
+			}
+		}
+	}
+	hasSource := len(xf.File.Source) > 0
+
+	missed := []int{}
+	taken := map[int]bool{}
+	place := func(from, to, n int, skip func(int) bool) int {
+		for l := from; l <= to && n > 0; l++ {
+			if _, ok := covered[l]; ok || taken[l] || skip(l) || (hasSource && !code[l]) {
+				continue
+			}
+			taken[l] = true
+			missed = append(missed, l)
+			n--
+		}
+		return n
+	}
+
+	methods := []xmlMethod{}
+	for _, u := range append(append([]xmlUnit{}, xf.File.Classes...), xf.File.Traits...) {
+		methods = append(methods, u.Methods...)
+	}
+	inMethod := func(l int) bool {
+		for _, m := range methods {
+			if l >= m.Start && l <= m.End {
+				return true
+			}
+		}
+		return false
+	}
+	none := func(int) bool { return false }
+
+	for _, m := range methods {
+		end := m.End
+		if !hasSource {
+			// the last line of a method closes it
+			end--
+		}
+		place(m.Start+1, end, m.Executable-m.Executed, none)
+	}
+
+	left := xf.File.Totals.Lines.Executable - xf.File.Totals.Lines.Executed - len(missed)
+	if left > 0 && hasSource {
+		left = place(1, xf.File.Source[len(xf.File.Source)-1].Num, left, inMethod)
+	}
+	if left < 0 {
+		left = 0
+	}
+
+	sort.Ints(missed)
+	return missed, left
+}
+
+// hrefs lists the files of a directory and of all the directories in it.
+func (d xmlDirectory) hrefs() []string {
+	hrefs := []string{}
+	for _, f := range d.Files {
+		hrefs = append(hrefs, f.Href)
+	}
+	for _, sub := range d.Directories {
+		hrefs = append(hrefs, sub.hrefs()...)
+	}
+	sort.Strings(hrefs)
+	return hrefs
+}
+
+func readXML(path string, v interface{}) error {
+	fx, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fx.Close()
+
+	if err := xml.NewDecoder(fx).Decode(v); err != nil {
+		return errors.Wrapf(err, "could not read %s", path)
+	}
+	return nil
+}
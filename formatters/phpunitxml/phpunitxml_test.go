@@ -0,0 +1,75 @@
+package phpunitxml
+
+import (
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{}
+	p, err := f.Search("example/index.xml")
+	r.NoError(err)
+	r.Equal("example", p)
+
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+	// the totals of index.xml
+	r.Equal(8, rep.LineCounts.Total)
+	r.Equal(5, rep.LineCounts.Covered)
+
+	sf := rep.SourceFiles["/home/ci/app/src/Calculator.php"]
+	r.Len(sf.Coverage, 24)
+	r.False(sf.Coverage[7].Valid)
+	// line 9 was covered by two tests
+	r.Equal(formatters.NewNullInt(2), sf.Coverage[8])
+	r.Equal(formatters.NewNullInt(1), sf.Coverage[13])
+	// the lines of code no test covered are taken from the source
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[14])
+	r.False(sf.Coverage[15].Valid)
+	r.False(sf.Coverage[22].Valid)
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[23])
+	r.Equal(5, sf.LineCounts.Total)
+	r.Equal(3, sf.LineCounts.Covered)
+	r.Nil(sf.CoveredBy)
+
+	// without the source, the first line of slug's body no test covered
+	sf = rep.SourceFiles["/home/ci/app/src/Util/Str.php"]
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[7])
+	r.Equal(3, sf.LineCounts.Total)
+	r.Equal(2, sf.LineCounts.Covered)
+}
+
+func Test_Format_KeepTests(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "example", KeepTests: true}
+	rep, err := f.Format()
+	r.NoError(err)
+
+	r.Equal(map[int][]string{
+		9:  {"Tests\\CalculatorTest::testAdd", "Tests\\CalculatorTest::testDivide"},
+		14: {"Tests\\CalculatorTest::testDivide"},
+		18: {"Tests\\CalculatorTest::testDivide"},
+	}, rep.SourceFiles["/home/ci/app/src/Calculator.php"].CoveredBy)
+	r.Equal([]string{"Tests\\StrTest::testSlug"}, rep.SourceFiles["/home/ci/app/src/Util/Str.php"].CoveredBy[12])
+}
@@ -0,0 +1,58 @@
+package phpunitxml
+
+type xmlIndex struct {
+	Project struct {
+		Source    string       `xml:"source,attr"`
+		Directory xmlDirectory `xml:"directory"`
+	} `xml:"project"`
+}
+
+type xmlDirectory struct {
+	Name  string `xml:"name,attr"`
+	Files []struct {
+		Name string `xml:"name,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+	Directories []xmlDirectory `xml:"directory"`
+}
+
+type xmlFile struct {
+	File struct {
+		Name   string `xml:"name,attr"`
+		Path   string `xml:"path,attr"`
+		Totals struct {
+			Lines struct {
+				Total      int `xml:"total,attr"`
+				Executable int `xml:"executable,attr"`
+				Executed   int `xml:"executed,attr"`
+			} `xml:"lines"`
+		} `xml:"totals"`
+		Classes []xmlUnit `xml:"class"`
+		Traits  []xmlUnit `xml:"trait"`
+		Lines   []struct {
+			Num     int `xml:"nr,attr"`
+			Covered []struct {
+				By string `xml:"by,attr"`
+			} `xml:"covered"`
+		} `xml:"coverage>line"`
+		Source []struct {
+			Num    int `xml:"no,attr"`
+			Tokens []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"token"`
+		} `xml:"source>line"`
+	} `xml:"file"`
+}
+
+// xmlUnit is a class or a trait.
+type xmlUnit struct {
+	Methods []xmlMethod `xml:"method"`
+}
+
+type xmlMethod struct {
+	Name       string `xml:"name,attr"`
+	Start      int    `xml:"start,attr"`
+	End        int    `xml:"end,attr"`
+	Executable int    `xml:"executable,attr"`
+	Executed   int    `xml:"executed,attr"`
+}
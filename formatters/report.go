@@ -6,23 +6,34 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/codeclimate/test-reporter/env"
 	"github.com/codeclimate/test-reporter/version"
 	"github.com/gobuffalo/envy"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 type Report struct {
-	CIService       ccCIService `json:"ci_service"`
-	Environment     Environment `json:"environment"`
-	Git             ccGit       `json:"git"`
-	CoveredPercent  float64     `json:"covered_percent"`
-	CoveredStrength int         `json:"covered_strength"`
-	LineCounts      LineCounts  `json:"line_counts"`
-	SourceFiles     SourceFiles `json:"source_files"`
-	RepoToken       string      `json:"repo_token"`
+	CIService            ccCIService `json:"ci_service"`
+	Environment          Environment `json:"environment"`
+	Git                  ccGit       `json:"git"`
+	CoveredPercent       float64     `json:"covered_percent"`
+	CoveredStrength      int         `json:"covered_strength"`
+	LineCounts           LineCounts  `json:"line_counts"`
+	BranchCoveredPercent float64     `json:"branch_covered_percent"`
+	BranchCounts         LineCounts  `json:"branch_counts"`
+	FunctionCounts       LineCounts  `json:"function_counts"`
+	SourceFiles          SourceFiles `json:"source_files"`
+	RepoToken            string      `json:"repo_token"`
+	// the files a FileFilter left out, by the exclude pattern that
+	// matched them
+	ExcludedFiles map[string][]string `json:"excluded_files,omitempty"`
+	// the files a FileFilter left out because no include pattern matched
+	// them
+	NotIncludedFiles []string `json:"not_included_files,omitempty"`
 }
 
 type ccCIService struct {
@@ -54,7 +65,6 @@ func newEnvironment() Environment {
 	cc := Environment{
 		RailsRoot:       envy.Get("RAILS_ROOT", ""),
 		ReporterVersion: version.Version,
-		Prefix:          envy.Get("PREFIX", ""),
 	}
 
 	pwd, _ := os.Getwd()
@@ -109,6 +119,7 @@ func (a *Report) Merge(reps ...*Report) error {
 		if a.Git.Head != r.Git.Head {
 			return errors.New("git heads do not match")
 		}
+		a.mergeExcluded(r)
 		for _, sf := range r.SourceFiles {
 			err := a.AddSourceFile(sf)
 			if err != nil {
@@ -119,15 +130,51 @@ func (a *Report) Merge(reps ...*Report) error {
 	return nil
 }
 
+// LimitHits lowers the hits of the lines above max to max. Merging reports
+// of coverage that only tells whether lines ran adds up their hits; limiting
+// them to 1 afterwards gives whether any of the reports had them run.
+func (rep *Report) LimitHits(max int) {
+	files := rep.SourceFiles
+	rep.SourceFiles = SourceFiles{}
+	rep.LineCounts = LineCounts{}
+	rep.BranchCounts = LineCounts{}
+	rep.FunctionCounts = LineCounts{}
+	for _, sf := range files {
+		for i, c := range sf.Coverage {
+			if c.Valid && c.Int > max {
+				sf.Coverage[i] = NewNullInt(max)
+			}
+		}
+		// the names are all different, so there's nothing to merge and
+		// nothing to fail
+		rep.AddSourceFile(sf)
+	}
+}
+
 func (rep *Report) AddSourceFile(sf SourceFile) error {
 	var err error
 
+	if sf.dropped {
+		return nil
+	}
+
+	if sf.excluded {
+		rep.exclude(sf.Name, sf.excludedBy)
+		return nil
+	}
+
 	// check if we already know about this file
 	if s, ok := rep.SourceFiles[sf.Name]; ok {
 		// remove the old values... we know more now
 		rep.LineCounts.Covered -= s.LineCounts.Covered
 		rep.LineCounts.Missed -= s.LineCounts.Missed
 		rep.LineCounts.Total -= s.LineCounts.Total
+		rep.BranchCounts.Covered -= s.BranchCounts.Covered
+		rep.BranchCounts.Missed -= s.BranchCounts.Missed
+		rep.BranchCounts.Total -= s.BranchCounts.Total
+		rep.FunctionCounts.Covered -= s.FunctionCounts.Covered
+		rep.FunctionCounts.Missed -= s.FunctionCounts.Missed
+		rep.FunctionCounts.Total -= s.FunctionCounts.Total
 
 		sf, err = s.Merge(sf)
 		if err != nil {
@@ -140,8 +187,56 @@ func (rep *Report) AddSourceFile(sf SourceFile) error {
 	rep.LineCounts.Covered += sf.LineCounts.Covered
 	rep.LineCounts.Missed += sf.LineCounts.Missed
 	rep.LineCounts.Total += sf.LineCounts.Total
+	rep.BranchCounts.Covered += sf.BranchCounts.Covered
+	rep.BranchCounts.Missed += sf.BranchCounts.Missed
+	rep.BranchCounts.Total += sf.BranchCounts.Total
+	rep.FunctionCounts.Covered += sf.FunctionCounts.Covered
+	rep.FunctionCounts.Missed += sf.FunctionCounts.Missed
+	rep.FunctionCounts.Total += sf.FunctionCounts.Total
 
 	rep.CoveredPercent = rep.LineCounts.CoveredPercent()
+	rep.BranchCoveredPercent = rep.BranchCounts.CoveredPercent()
+	return nil
+}
+
+// ResolveBlobIDs computes the blob ids NewSourceFile left pending because
+// of DeferBlobs, hashing up to jobs files at a time.
+func (rep *Report) ResolveBlobIDs(jobs int) error {
+	names := []string{}
+	for name, sf := range rep.SourceFiles {
+		if sf.blobPath != "" {
+			names = append(names, name)
+		}
+	}
+	// resolving in a fixed order keeps any error the same from run to run
+	sort.Strings(names)
+
+	pending := map[*object.Commit][]string{}
+	commits := []*object.Commit{}
+	for _, name := range names {
+		sf := rep.SourceFiles[name]
+		if _, ok := pending[sf.blobCommit]; !ok {
+			commits = append(commits, sf.blobCommit)
+		}
+		pending[sf.blobCommit] = append(pending[sf.blobCommit], sf.blobPath)
+	}
+
+	for _, commit := range commits {
+		blobs, err := env.GitBlobs(pending[commit], commit, jobs)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			sf := rep.SourceFiles[name]
+			if sf.blobCommit != commit {
+				continue
+			}
+			sf.BlobID = blobs[sf.blobPath]
+			sf.blobPath = ""
+			sf.blobCommit = nil
+			rep.SourceFiles[name] = sf
+		}
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/codeclimate/test-reporter/env"
 	"github.com/stretchr/testify/require"
 )
 
@@ -155,3 +156,55 @@ func Test_Merge_Issue_103(t *testing.T) {
 	r.Equal(8, a.LineCounts.Covered)
 	r.Equal(8, a.LineCounts.Total)
 }
+
+func Test_Report_AddSourceFile_Branches(t *testing.T) {
+	r := require.New(t)
+
+	rep, err := NewReport()
+	r.NoError(err)
+
+	err = rep.AddSourceFile(SourceFile{
+		Name:     "a.go",
+		Coverage: Coverage{NewNullInt(1)},
+		Branches: Branches{{Line: 1, ID: "0", Taken: 1}, {Line: 1, ID: "1", Taken: 0}},
+	})
+	r.NoError(err)
+	r.Equal(2, rep.BranchCounts.Total)
+	r.InDelta(50, rep.BranchCoveredPercent, 1)
+
+	err = rep.AddSourceFile(SourceFile{
+		Name:     "a.go",
+		Coverage: Coverage{NewNullInt(1)},
+		Branches: Branches{{Line: 1, ID: "1", Taken: 2}},
+	})
+	r.NoError(err)
+	r.Equal(2, rep.BranchCounts.Total)
+	r.Equal(2, rep.BranchCounts.Covered)
+	r.Equal(0, rep.BranchCounts.Missed)
+	r.InDelta(100, rep.BranchCoveredPercent, 1)
+}
+
+func Test_Report_ResolveBlobIDs(t *testing.T) {
+	r := require.New(t)
+
+	rep, err := NewReport()
+	r.NoError(err)
+
+	for _, name := range []string{"./coverage.go", "./report.go", "./coverage.go"} {
+		sf, err := NewSourceFile(name, nil, SourceFileOptions{DeferBlobs: true})
+		r.NoError(err)
+		r.Zero(sf.BlobID)
+		r.NoError(rep.AddSourceFile(sf))
+	}
+
+	_, err = NewSourceFile("./missing.go", nil, SourceFileOptions{DeferBlobs: true})
+	r.Error(err)
+
+	r.NoError(rep.ResolveBlobIDs(2))
+	r.Len(rep.SourceFiles, 2)
+	for name, sf := range rep.SourceFiles {
+		blob, err := env.GitBlob(name, nil)
+		r.NoError(err)
+		r.Equal(blob, sf.BlobID)
+	}
+}
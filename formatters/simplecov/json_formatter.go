@@ -47,7 +47,7 @@ func reportIsOnLegacyFormat(simplecovVersion string) bool {
 
 func transformLineCoverageToCoverage(ln []interface{}) formatters.Coverage {
 	coverage := make([]formatters.NullInt, len(ln))
-	ignoredLine := formatters.NullInt{-1, false}
+	ignoredLine := formatters.NullInt{Int: -1, Valid: false}
 	var convertedCoverageValue int
 	for i := 0; i < len(ln); i++ {
 		_, ok := ln[i].(string)
@@ -66,6 +66,24 @@ func transformLineCoverageToCoverage(ln []interface{}) formatters.Coverage {
 	return coverage
 }
 
+// transformBranches drops the branches simplecov was told to ignore, which
+// are reported with a string coverage instead of a number.
+func transformBranches(bs []branch) formatters.Branches {
+	branches := formatters.Branches{}
+	for i, b := range bs {
+		taken, ok := b.Coverage.(float64)
+		if !ok {
+			continue
+		}
+		branches = append(branches, formatters.Branch{
+			Line:  b.StartLine,
+			ID:    fmt.Sprintf("%d:%s", i, b.Type),
+			Taken: int(taken),
+		})
+	}
+	return branches
+}
+
 func jsonFormat(r Formatter, rep formatters.Report) (formatters.Report, error) {
 	logrus.Debugf("Analyzing simplecov json output from latest format %s", r.Path)
 	jf, err := os.Open(r.Path)
@@ -88,11 +106,12 @@ func jsonFormat(r Formatter, rep formatters.Report) (formatters.Report, error) {
 
 	gitHead, _ := env.GetHead()
 	for n, ls := range m.CoverageType {
-		fe, err := formatters.NewSourceFile(n, gitHead)
+		fe, err := formatters.NewSourceFile(n, gitHead, r.Options)
 		if err != nil {
 			return rep, errors.WithStack(err)
 		}
 		fe.Coverage = transformLineCoverageToCoverage(ls.LineCoverage)
+		fe.Branches = transformBranches(ls.Branches)
 		err = rep.AddSourceFile(fe)
 		if err != nil {
 			return rep, errors.WithStack(err)
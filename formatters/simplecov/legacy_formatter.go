@@ -31,7 +31,7 @@ func legacyFormat(r Formatter, rep formatters.Report) (formatters.Report, error)
         gitHead, _ := env.GetHead()
         for _, v := range m {
           for n, ls := range v.Coverage {
-                  fe, err := formatters.NewSourceFile(n, gitHead)
+                  fe, err := formatters.NewSourceFile(n, gitHead, r.Options)
                   if err != nil {
                           return rep, errors.WithStack(err)
                   }
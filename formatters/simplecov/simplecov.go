@@ -12,7 +12,8 @@ import (
 var searchPaths = []string{"coverage/coverage.json", "coverage/.resultset.json"}
 
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -6,6 +6,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -186,6 +187,12 @@ func Test_FormatWithBranch(t *testing.T) {
 
 	assert.InDelta(97.95, rep.CoveredPercent, 1)
 
+	bf := rep.SourceFiles["development/mygem/lib/mygem/definition_manager.rb"]
+	assert.Len(bf.Branches, 4)
+	assert.Equal(formatters.Branch{Line: 16, ID: "1:else", Taken: 1}, bf.Branches[1])
+	assert.Equal(1, bf.BranchCounts.Covered)
+	assert.Equal(3, bf.BranchCounts.Missed)
+
 	sf := rep.SourceFiles["development/mygem/lib/mygem/wrap.rb"]
 	assert.InDelta(100, sf.CoveredPercent, 1)
 
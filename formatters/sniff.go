@@ -0,0 +1,147 @@
+package formatters
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// XMLElements returns the names of the first n elements of an XML file,
+// in document order, without reading the rest of the file.
+func XMLElements(path string, n int) ([]string, error) {
+	names := []string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return names, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	d := xml.NewDecoder(f)
+	d.Strict = false
+	for len(names) < n {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return names, errors.WithStack(err)
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			names = append(names, se.Name.Local)
+		}
+	}
+	return names, nil
+}
+
+// JSONKeys returns the keys of the top level object of a JSON file and
+// the keys of any objects directly nested in it, the latter joined to
+// their parent key with a ".", e.g. "RSpec.coverage". Values are skipped
+// over, not decoded.
+func JSONKeys(path string) ([]string, error) {
+	keys := []string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return keys, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	d := json.NewDecoder(bufio.NewReader(f))
+	t, err := d.Token()
+	if err != nil {
+		return keys, errors.WithStack(err)
+	}
+	if t != json.Delim('{') {
+		return keys, nil
+	}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return keys, errors.WithStack(err)
+		}
+		key := t.(string)
+		keys = append(keys, key)
+
+		t, err = d.Token()
+		if err != nil {
+			return keys, errors.WithStack(err)
+		}
+		if t != json.Delim('{') {
+			if err := skipJSONValue(d, t); err != nil {
+				return keys, err
+			}
+			continue
+		}
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return keys, errors.WithStack(err)
+			}
+			keys = append(keys, key+"."+t.(string))
+			t, err = d.Token()
+			if err != nil {
+				return keys, errors.WithStack(err)
+			}
+			if err := skipJSONValue(d, t); err != nil {
+				return keys, err
+			}
+		}
+		// closing "}" of the nested object
+		if _, err := d.Token(); err != nil {
+			return keys, errors.WithStack(err)
+		}
+	}
+	return keys, nil
+}
+
+// skipJSONValue reads past the rest of the value that starts with t.
+func skipJSONValue(d *json.Decoder, t json.Token) error {
+	if t != json.Delim('{') && t != json.Delim('[') {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		t, err := d.Token()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// HasLinePrefix reports whether any of the first n non-blank lines of a
+// file start with one of the prefixes.
+func HasLinePrefix(path string, n int, prefixes ...string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() && n > 0 {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		n--
+		for _, p := range prefixes {
+			if strings.HasPrefix(line, p) {
+				return true, nil
+			}
+		}
+	}
+	return false, errors.WithStack(scanner.Err())
+}
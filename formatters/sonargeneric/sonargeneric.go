@@ -0,0 +1,149 @@
+package sonargeneric
+
+import (
+	"encoding/xml"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+)
+
+var searchPaths = []string{"coverage/sonar-coverage.xml", "sonar-coverage.xml"}
+
+// Formatter reads SonarQube's generic test coverage format, which any
+// tool the reporter doesn't know about can be made to write.
+type Formatter struct {
+	Path    string
+	Options formatters.SourceFileOptions
+}
+
+func (f *Formatter) Search(paths ...string) (string, error) {
+	paths = append(paths, searchPaths...)
+	for _, p := range paths {
+		logrus.Debugf("checking search path %s for sonar-generic formatter", p)
+		if _, err := os.Stat(p); err == nil {
+			f.Path = p
+			return p, nil
+		}
+	}
+
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for sonar-generic. search paths were: %s", strings.Join(paths, ", ")))
+}
+
+// lineCoverage is what the <file> elements of one path say about a line.
+type lineCoverage struct {
+	hits            int
+	branchesToCover int
+	coveredBranches int
+}
+
+// Format reads every <file> element into one source file per path. The
+// format only says whether a line was covered, so a line gets a hit for
+// each <file> element of its path that has it covered.
+func (r Formatter) Format() (formatters.Report, error) {
+	rep, err := formatters.NewReport()
+	if err != nil {
+		return rep, err
+	}
+
+	fx, err := os.Open(r.Path)
+	if err != nil {
+		return rep, errors.WithStack(err)
+	}
+	defer fx.Close()
+
+	paths := []string{}
+	files := map[string]map[int]*lineCoverage{}
+	err = formatters.StreamXML(fx, "coverage", func(d *xml.Decoder, t xml.Token) error {
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "file" {
+			return nil
+		}
+
+		xf := xmlFile{}
+		if err := d.DecodeElement(&xf, &se); err != nil {
+			return errors.WithStack(err)
+		}
+		if xf.Path == "" {
+			return errors.Errorf("found a <file> element without a path in %s", r.Path)
+		}
+
+		lines, ok := files[xf.Path]
+		if !ok {
+			lines = map[int]*lineCoverage{}
+			files[xf.Path] = lines
+			paths = append(paths, xf.Path)
+		}
+		for _, l := range xf.Lines {
+			if l.Num < 1 {
+				return errors.Errorf("found a line numbered %d for %s in %s", l.Num, xf.Path, r.Path)
+			}
+			lc, ok := lines[l.Num]
+			if !ok {
+				lc = &lineCoverage{}
+				lines[l.Num] = lc
+			}
+			if l.Covered {
+				lc.hits++
+			}
+			// which branches were covered isn't known, so entries for the
+			// same line can't be added up
+			if l.BranchesToCover > lc.branchesToCover {
+				lc.branchesToCover = l.BranchesToCover
+			}
+			if l.CoveredBranches > lc.coveredBranches {
+				lc.coveredBranches = l.CoveredBranches
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	gitHead, _ := env.GetHead()
+	for _, path := range paths {
+		sf, err := formatters.NewSourceFile(path, gitHead, r.Options)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		sf.Coverage, sf.Branches = coverage(files[path])
+		err = rep.AddSourceFile(sf)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+	}
+
+	return rep, nil
+}
+
+func coverage(lines map[int]*lineCoverage) (formatters.Coverage, formatters.Branches) {
+	last := 0
+	for num := range lines {
+		if num > last {
+			last = num
+		}
+	}
+
+	cov := make(formatters.Coverage, last)
+	branches := formatters.Branches{}
+	for num := 1; num <= last; num++ {
+		lc, ok := lines[num]
+		if !ok {
+			continue
+		}
+		cov[num-1] = formatters.NewNullInt(lc.hits)
+		for i := 0; i < lc.branchesToCover; i++ {
+			taken := 0
+			if i < lc.coveredBranches {
+				taken = 1
+			}
+			branches = append(branches, formatters.Branch{Line: num, ID: strconv.Itoa(i), Taken: taken})
+		}
+	}
+	return cov, branches
+}
@@ -0,0 +1,53 @@
+package sonargeneric
+
+import (
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	f := &Formatter{Path: "./example.xml"}
+	rep, err := f.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+
+	// parser.ts has two <file> elements
+	sf := rep.SourceFiles["src/lib/parser.ts"]
+	r.Equal(formatters.Coverage{
+		formatters.NullInt{},
+		formatters.NullInt{},
+		formatters.NewNullInt(1),
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(0),
+		formatters.NullInt{},
+		formatters.NewNullInt(1),
+		formatters.NullInt{},
+		formatters.NewNullInt(1),
+	}, sf.Coverage)
+	r.Equal(formatters.Branches{
+		{Line: 4, ID: "0", Taken: 1},
+		{Line: 4, ID: "1", Taken: 1},
+	}, sf.Branches)
+	r.Equal(5, sf.LineCounts.Total)
+	r.Equal(4, sf.LineCounts.Covered)
+
+	sf = rep.SourceFiles["src/lib/format.ts"]
+	r.Equal(2, sf.LineCounts.Missed)
+	r.Len(sf.Branches, 0)
+
+	r.Equal(7, rep.LineCounts.Total)
+	r.Equal(4, rep.LineCounts.Covered)
+}
@@ -0,0 +1,11 @@
+package sonargeneric
+
+type xmlFile struct {
+	Path  string `xml:"path,attr"`
+	Lines []struct {
+		Num             int  `xml:"lineNumber,attr"`
+		Covered         bool `xml:"covered,attr"`
+		BranchesToCover int  `xml:"branchesToCover,attr"`
+		CoveredBranches int  `xml:"coveredBranches,attr"`
+	} `xml:"lineToCover"`
+}
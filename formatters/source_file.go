@@ -4,23 +4,42 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/codeclimate/test-reporter/env"
-	"github.com/gobuffalo/envy"
 	"github.com/pkg/errors"
 )
 
 type SourceFile struct {
-	BlobID          string     `json:"blob_id"`
-	Coverage        Coverage   `json:"coverage"`
-	CoveredPercent  float64    `json:"covered_percent"`
-	CoveredStrength float64    `json:"covered_strength"`
-	LineCounts      LineCounts `json:"line_counts"`
-	Name            string     `json:"name"`
+	BlobID               string     `json:"blob_id"`
+	Coverage             Coverage   `json:"coverage"`
+	CoveredPercent       float64    `json:"covered_percent"`
+	CoveredStrength      float64    `json:"covered_strength"`
+	LineCounts           LineCounts `json:"line_counts"`
+	Branches             Branches   `json:"branches,omitempty"`
+	BranchCounts         LineCounts `json:"branch_counts"`
+	BranchCoveredPercent float64    `json:"branch_covered_percent"`
+	Functions            Functions  `json:"functions,omitempty"`
+	FunctionCounts       LineCounts `json:"function_counts"`
+	Name                 string     `json:"name"`
+	// the names of the tests that covered each line, by line number, for
+	// the formatters that know them and are asked to keep them
+	CoveredBy map[int][]string `json:"covered_by,omitempty"`
+
+	// set when computing the blob id is left to Report.ResolveBlobIDs
+	blobPath   string
+	blobCommit *object.Commit
+	// set when no path map rule matched the file and it's left out
+	dropped bool
+	// set when the filter left the file out, along with the exclude
+	// pattern that matched it, if any
+	excluded   bool
+	excludedBy string
 }
 
 func (a SourceFile) Merge(b SourceFile) (SourceFile, error) {
@@ -55,6 +74,18 @@ func (a SourceFile) Merge(b SourceFile) (SourceFile, error) {
 		}
 
 	}
+	a.Branches = a.Branches.Merge(b.Branches)
+	a.Functions = a.Functions.Merge(b.Functions)
+	if len(b.CoveredBy) > 0 {
+		coveredBy := map[int][]string{}
+		for l, tests := range a.CoveredBy {
+			coveredBy[l] = append(coveredBy[l], tests...)
+		}
+		for l, tests := range b.CoveredBy {
+			coveredBy[l] = append(coveredBy[l], tests...)
+		}
+		a.CoveredBy = coveredBy
+	}
 	a.CalcLineCounts()
 	return a, nil
 }
@@ -77,10 +108,47 @@ func (sf *SourceFile) CalcLineCounts() {
 	sf.LineCounts = lc
 	sf.CoveredPercent = lc.CoveredPercent()
 	sf.CoveredStrength = lc.CoveredStrength()
+
+	sf.BranchCounts = sf.Branches.Counts()
+	sf.BranchCoveredPercent = sf.BranchCounts.CoveredPercent()
+
+	sf.FunctionCounts = sf.Functions.Counts()
 }
 
-func NewSourceFile(name string, commit *object.Commit) (SourceFile, error) {
-	if prefix, err := envy.MustGet("PREFIX"); err == nil {
+// SourceFileOptions tell NewSourceFile how to turn the file names found in
+// coverage files into names of files in the repo. format-coverage sets
+// them from its flags and passes them to the formatters.
+type SourceFileOptions struct {
+	// Root is joined to relative names, for coverage files whose names
+	// are relative to their own project directories
+	Root string
+	// PathMap rewrites the names, after Root is joined to them
+	PathMap PathMap
+	// Prefix is trimmed from the names the path map gives, and AddPrefix
+	// then put in front of them
+	Prefix    string
+	AddPrefix string
+	// Filter leaves files out before their blob ids are looked up, so
+	// they don't need to exist
+	Filter FileFilter
+	// DeferBlobs only checks that the blob ids of the files can be
+	// computed, leaving computing them to Report.ResolveBlobIDs, which
+	// does them all at once
+	DeferBlobs bool
+}
+
+func NewSourceFile(name string, commit *object.Commit, opts SourceFileOptions) (SourceFile, error) {
+	if opts.Root != "" && !filepath.IsAbs(name) {
+		name = filepath.Join(opts.Root, name)
+	}
+
+	name, matched := opts.PathMap.Apply(name)
+	if !matched && opts.PathMap.DropUnmatched {
+		logrus.Debugf("no path map rule matched %s, leaving it out of the report", name)
+		return SourceFile{Name: name, Coverage: Coverage{}, dropped: true}, nil
+	}
+
+	if prefix := opts.Prefix; prefix != "" {
 		if strings.HasSuffix(prefix, string(os.PathSeparator)) {
 			name = strings.TrimPrefix(name, prefix)
 			logrus.Printf("trimming with prefix %s", prefix)
@@ -96,20 +164,32 @@ func NewSourceFile(name string, commit *object.Commit) (SourceFile, error) {
 		Coverage: Coverage{},
 	}
 
-	var err error
-	sf.BlobID, err = env.GitBlob(name, commit)
+	if addPrefix := opts.AddPrefix; addPrefix != "" {
+		if strings.HasSuffix(addPrefix, string(os.PathSeparator)) {
+			sf.Name = addPrefix + sf.Name
+		} else {
+			sf.Name = addPrefix + string(os.PathSeparator) + sf.Name
+		}
+	}
 
-	if err != nil {
-		return sf, errors.WithStack(err)
+	if keep, pattern := opts.Filter.Match(sf.Name); !keep {
+		sf.excluded = true
+		sf.excludedBy = pattern
+		return sf, nil
 	}
 
-	if addPrefix, err := envy.MustGet("ADD_PREFIX"); err == nil {
-		if addPrefix != "" {
-			if strings.HasSuffix(addPrefix, string(os.PathSeparator)) {
-				sf.Name = addPrefix + sf.Name
-			} else {
-				sf.Name = addPrefix + string(os.PathSeparator) + sf.Name
-			}
+	if opts.DeferBlobs {
+		if err := env.CheckBlob(name, commit); err != nil {
+			return sf, errors.WithStack(err)
+		}
+		sf.blobPath = name
+		sf.blobCommit = commit
+	} else {
+		var err error
+		sf.BlobID, err = env.GitBlob(name, commit)
+
+		if err != nil {
+			return sf, errors.WithStack(err)
 		}
 	}
 
@@ -119,8 +199,16 @@ func NewSourceFile(name string, commit *object.Commit) (SourceFile, error) {
 type SourceFiles map[string]SourceFile
 
 func (sf SourceFiles) MarshalJSON() ([]byte, error) {
+	// sorted by name so the same report is always written the same way
+	names := make([]string, 0, len(sf))
+	for name := range sf {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	files := []SourceFile{}
-	for _, s := range sf {
+	for _, name := range names {
+		s := sf[name]
 		s.CalcLineCounts()
 		files = append(files, s)
 	}
@@ -3,9 +3,9 @@ package formatters
 import (
 	"testing"
 
+	"os"
 	"path/filepath"
 
-	"github.com/gobuffalo/envy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -73,7 +73,7 @@ func Test_SourceFile_Merge_With_Different_Lens(t *testing.T) {
 }
 func Test_SourceFile_BlobID(t *testing.T) {
 	r := require.New(t)
-	sf, err := NewSourceFile("./coverage.go", nil)
+	sf, err := NewSourceFile("./coverage.go", nil, SourceFileOptions{})
 	r.NoError(err)
 	r.NotZero(sf.BlobID)
 	r.NotContains(sf.BlobID, "blob")
@@ -98,54 +98,141 @@ func Test_SourceFile_Merge_With_Mismatch_Blob_Id(t *testing.T) {
 }
 
 func Test_SourceFile_AddPrefix(t *testing.T) {
-	envy.Temp(func() {
-		envy.Set("ADD_PREFIX", "test-prefix")
-		envy.Set("PREFIX", ".")
-		r := require.New(t)
-		sf, err := NewSourceFile("coverage.go", nil)
-		r.NoError(err)
-		r.Equal(sf.Name, filepath.Join("test-prefix", "coverage.go"))
-	})
+	r := require.New(t)
+	sf, err := NewSourceFile("coverage.go", nil, SourceFileOptions{Prefix: ".", AddPrefix: "test-prefix"})
+	r.NoError(err)
+	r.Equal(sf.Name, filepath.Join("test-prefix", "coverage.go"))
 }
 
 func Test_SourceFile_AddPrefixWithPathSeparator(t *testing.T) {
-	envy.Temp(func() {
-		envy.Set("ADD_PREFIX", filepath.Join("test-prefix"))
-		envy.Set("PREFIX", ".")
-		r := require.New(t)
-		sf, err := NewSourceFile("coverage.go", nil)
-		r.NoError(err)
-		r.Equal(sf.Name, filepath.Join("test-prefix","coverage.go"))
-	})
+	r := require.New(t)
+	sf, err := NewSourceFile("coverage.go", nil, SourceFileOptions{Prefix: ".", AddPrefix: "test-prefix" + string(os.PathSeparator)})
+	r.NoError(err)
+	r.Equal(sf.Name, filepath.Join("test-prefix", "coverage.go"))
 }
 
 func Test_SourceFilePrefix(t *testing.T) {
-	envy.Temp(func() {
-		envy.Set("PREFIX", ".")
-		r := require.New(t)
-		sf, err := NewSourceFile("coverage.go", nil)
-		r.NoError(err)
-		r.Equal(sf.Name, "coverage.go")
-	})
+	r := require.New(t)
+	sf, err := NewSourceFile("coverage.go", nil, SourceFileOptions{Prefix: "."})
+	r.NoError(err)
+	r.Equal(sf.Name, "coverage.go")
 }
 
 func Test_SourceFilePrefixWithPathSeparator(t *testing.T) {
-	envy.Temp(func() {
-		envy.Set("PREFIX", "./")
-		r := require.New(t)
-		sf, err := NewSourceFile("coverage.go", nil)
-		r.NoError(err)
-		r.Equal(sf.Name, "coverage.go")
-	})
+	r := require.New(t)
+	sf, err := NewSourceFile("coverage.go", nil, SourceFileOptions{Prefix: "./"})
+	r.NoError(err)
+	r.Equal(sf.Name, "coverage.go")
 }
 
 func Test_SourceFileEmptyAddPrefixDoesNothing(t *testing.T) {
-	envy.Temp(func() {
-		envy.Set("PREFIX", "./")
-		envy.Set("ADD_PREFIX", "")
-		r := require.New(t)
-		sf, err := NewSourceFile("coverage.go", nil)
+	r := require.New(t)
+	sf, err := NewSourceFile("coverage.go", nil, SourceFileOptions{Prefix: "./", AddPrefix: ""})
+	r.NoError(err)
+	r.Equal(sf.Name, "coverage.go")
+}
+
+func Test_SourceFile_Merge_With_Branches(t *testing.T) {
+	r := require.New(t)
+	a := SourceFile{
+		BlobID:   "a",
+		Coverage: Coverage{NewNullInt(1), NewNullInt(1)},
+		Branches: Branches{{Line: 1, ID: "0,0", Taken: 1}, {Line: 1, ID: "0,1", Taken: 0}},
+	}
+	b := SourceFile{
+		BlobID:   "a",
+		Coverage: Coverage{NewNullInt(1), NewNullInt(1)},
+		Branches: Branches{{Line: 2, ID: "1,0", Taken: 0}, {Line: 1, ID: "0,1", Taken: 3}},
+	}
+
+	c, err := a.Merge(b)
+	r.NoError(err)
+	r.Equal(Branches{{Line: 1, ID: "0,0", Taken: 1}, {Line: 1, ID: "0,1", Taken: 3}, {Line: 2, ID: "1,0", Taken: 0}}, c.Branches)
+	r.Equal(LineCounts{Total: 3, Missed: 1, Covered: 2, Strength: 4}, c.BranchCounts)
+	r.InDelta(66.6, c.BranchCoveredPercent, 1)
+}
+
+func Test_SourceFile_Merge_With_Functions(t *testing.T) {
+	r := require.New(t)
+	a := SourceFile{
+		BlobID:    "a",
+		Coverage:  Coverage{NewNullInt(1), NewNullInt(0)},
+		Functions: Functions{{Name: "main", StartLine: 1, Hits: 1}, {Name: "helper", StartLine: 2}},
+	}
+	b := SourceFile{
+		BlobID:    "a",
+		Coverage:  Coverage{NewNullInt(1), NewNullInt(1)},
+		Functions: Functions{{Name: "helper", StartLine: 2, EndLine: 4, Hits: 2}},
+	}
+
+	c, err := a.Merge(b)
+	r.NoError(err)
+	r.Equal(Functions{{Name: "main", StartLine: 1, Hits: 1}, {Name: "helper", StartLine: 2, EndLine: 4, Hits: 2}}, c.Functions)
+	r.Equal(LineCounts{Total: 2, Missed: 0, Covered: 2, Strength: 3}, c.FunctionCounts)
+}
+
+func Test_SourceFile_Merge_With_CoveredBy(t *testing.T) {
+	r := require.New(t)
+	a := SourceFile{
+		BlobID:    "a",
+		Coverage:  Coverage{NewNullInt(1), NewNullInt(0)},
+		CoveredBy: map[int][]string{1: {"testA"}},
+	}
+	b := SourceFile{
+		BlobID:    "a",
+		Coverage:  Coverage{NewNullInt(1), NewNullInt(1)},
+		CoveredBy: map[int][]string{1: {"testB"}, 2: {"testB"}},
+	}
+
+	c, err := a.Merge(b)
+	r.NoError(err)
+	r.Equal(map[int][]string{1: {"testA", "testB"}, 2: {"testB"}}, c.CoveredBy)
+	r.Equal([]string{"testA"}, a.CoveredBy[1])
+}
+
+func Test_SourceFile_PathMap(t *testing.T) {
+	r := require.New(t)
+
+	m, err := ParsePathMap([]string{`^/app/(.+)\.go$=>$1.go`})
+	r.NoError(err)
+	opts := SourceFileOptions{PathMap: m}
+
+	sf, err := NewSourceFile("/app/coverage.go", nil, opts)
+	r.NoError(err)
+	r.Equal("coverage.go", sf.Name)
+	r.NotZero(sf.BlobID)
+
+	// the rules see relative names joined to the root
+	sf, err = NewSourceFile("coverage.go", nil, SourceFileOptions{Root: "/app", PathMap: m})
+	r.NoError(err)
+	r.Equal("coverage.go", sf.Name)
+
+	opts.PathMap.DropUnmatched = true
+	sf, err = NewSourceFile("/elsewhere/coverage.go", nil, opts)
+	r.NoError(err)
+
+	rep := Report{SourceFiles: SourceFiles{}}
+	r.NoError(rep.AddSourceFile(sf))
+	r.Len(rep.SourceFiles, 0)
+}
+
+func Test_SourceFile_Filter(t *testing.T) {
+	r := require.New(t)
+
+	f, err := NewFileFilter(nil, []string{"generated/**"})
+	r.NoError(err)
+	rep := Report{SourceFiles: SourceFiles{}}
+	for _, deferBlobs := range []bool{false, true} {
+		opts := SourceFileOptions{Filter: f, DeferBlobs: deferBlobs}
+		// excluded files are left out before their blob ids are looked
+		// up, so they don't need to exist
+		sf, err := NewSourceFile("generated/missing.go", nil, opts)
 		r.NoError(err)
-		r.Equal(sf.Name, "coverage.go")
-	})
+		r.NoError(rep.AddSourceFile(sf))
+
+		_, err = NewSourceFile("missing.go", nil, opts)
+		r.Error(err)
+	}
+	r.Len(rep.SourceFiles, 0)
+	r.Equal(map[string][]string{"generated/**": {"generated/missing.go"}}, rep.ExcludedFiles)
 }
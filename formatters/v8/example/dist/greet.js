@@ -0,0 +1,10 @@
+"use strict";
+Object.defineProperty(exports, "__esModule", { value: true });
+exports.greet = greet;
+function greet(name) {
+    if (name === "") {
+        return "hello, stranger";
+    }
+    return `hello, ${name}`;
+}
+//# sourceMappingURL=greet.js.map
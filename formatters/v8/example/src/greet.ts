@@ -0,0 +1,6 @@
+export function greet(name: string): string {
+  if (name === "") {
+    return "hello, stranger";
+  }
+  return `hello, ${name}`;
+}
@@ -0,0 +1,14 @@
+function add(a, b) {
+  return a + b;
+}
+
+function sign(n) {
+  if (n < 0) { return -1; }
+  return n > 0 ? 1 : 0;
+}
+
+function unused() {
+  return "é";
+}
+
+module.exports = { add, sign, unused };
@@ -0,0 +1,86 @@
+package v8
+
+import (
+	"sort"
+	"unicode"
+
+	"github.com/codeclimate/test-reporter/formatters"
+)
+
+// span is where the code of a line starts and ends, leading and trailing
+// whitespace left out. Offsets are in UTF-16 code units, as V8 counts
+// them, and end is exclusive; a blank line has start == end.
+type span struct {
+	start, end int
+}
+
+// sourceLines finds the span of each line of src.
+func sourceLines(src string) []span {
+	lines := []span{}
+	offset := 0
+	current := span{start: -1}
+	for _, r := range src {
+		if r == '\n' {
+			if current.start < 0 {
+				current = span{start: offset, end: offset}
+			}
+			lines = append(lines, current)
+			current = span{start: -1}
+			offset++
+			continue
+		}
+
+		// characters outside the basic multilingual plane take a
+		// surrogate pair
+		width := 1
+		if r > 0xFFFF {
+			width = 2
+		}
+		if !unicode.IsSpace(r) {
+			if current.start < 0 {
+				current.start = offset
+			}
+			current.end = offset + width
+		}
+		offset += width
+	}
+	if current.start >= 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// lineCoverage gives each line the count of the innermost range holding
+// all of it. V8 lists the range of a function before the ranges of the
+// blocks in it that ran a different number of times, and a function
+// before the functions nested in it, so applying the ranges outermost
+// first leaves each line with the right count.
+func lineCoverage(lines []span, ranges []coverageRange) formatters.Coverage {
+	sorted := append([]coverageRange{}, ranges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StartOffset != sorted[j].StartOffset {
+			return sorted[i].StartOffset < sorted[j].StartOffset
+		}
+		return sorted[i].EndOffset > sorted[j].EndOffset
+	})
+
+	coverage := make(formatters.Coverage, len(lines))
+	for _, r := range sorted {
+		first := sort.Search(len(lines), func(i int) bool { return lines[i].end > r.StartOffset })
+		for i := first; i < len(lines) && lines[i].start < r.EndOffset; i++ {
+			l := lines[i]
+			if l.start == l.end {
+				continue
+			}
+			if r.StartOffset <= l.start && l.end <= r.EndOffset {
+				coverage[i] = formatters.NewNullInt(r.Count)
+			}
+		}
+	}
+	return coverage
+}
+
+// lineOf finds the line, counting from 1, that offset is on.
+func lineOf(lines []span, offset int) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].end > offset }) + 1
+}
@@ -0,0 +1,160 @@
+package v8
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+)
+
+// sourceMap is a version 3 source map, as Node keeps them in the
+// source-map-cache of the coverage files.
+type sourceMap struct {
+	SourceRoot string   `json:"sourceRoot"`
+	Sources    []string `json:"sources"`
+	Mappings   string   `json:"mappings"`
+}
+
+type sourceMapCacheEntry struct {
+	URL  string     `json:"url"`
+	Data *sourceMap `json:"data"`
+}
+
+// segment maps the start of a generated line's code to a line of one of
+// the sources.
+type segment struct {
+	generatedLine int
+	source        int
+	sourceLine    int
+}
+
+// segments decodes the mappings, keeping those that name a source. Lines
+// count from 0.
+func (m sourceMap) segments() ([]segment, error) {
+	segments := []segment{}
+	// all fields but the generated column are relative to the segment
+	// before, across lines
+	source, sourceLine := 0, 0
+	for gl, line := range strings.Split(m.Mappings, ";") {
+		for _, s := range strings.Split(line, ",") {
+			if s == "" {
+				continue
+			}
+			fields, err := decodeVLQ(s)
+			if err != nil {
+				return segments, err
+			}
+			if len(fields) < 4 {
+				continue
+			}
+			source += fields[1]
+			sourceLine += fields[2]
+			segments = append(segments, segment{generatedLine: gl, source: source, sourceLine: sourceLine})
+		}
+	}
+	return segments, nil
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes the base 64 variable length quantities of a segment.
+func decodeVLQ(s string) ([]int, error) {
+	values := []int{}
+	value, shift := 0, uint(0)
+	for _, c := range s {
+		digit := strings.IndexRune(base64Digits, c)
+		if digit < 0 {
+			return values, errors.Errorf("invalid character %q in source map segment %s", c, s)
+		}
+		value |= (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		// the lowest bit is the sign
+		if value&1 == 1 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return values, errors.Errorf("source map segment %s ends in the middle of a value", s)
+	}
+	return values, nil
+}
+
+// apply moves the coverage of the generated lines to the lines of the
+// sources they were generated from, by path. A source line that several
+// generated lines map to only shows as covered if all of them ran.
+func (m sourceMap) apply(generated formatters.Coverage, base string) (map[string]formatters.Coverage, error) {
+	segments, err := m.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(m.Sources))
+	for i, s := range m.Sources {
+		paths[i] = resolveSource(m.source(s), base)
+	}
+
+	hits := map[string]map[int]int{}
+	for _, s := range segments {
+		if s.source < 0 || s.source >= len(paths) || paths[s.source] == "" {
+			continue
+		}
+		if s.generatedLine >= len(generated) || !generated[s.generatedLine].Valid {
+			continue
+		}
+		path := paths[s.source]
+		if hits[path] == nil {
+			hits[path] = map[int]int{}
+		}
+		h := generated[s.generatedLine].Int
+		if prev, ok := hits[path][s.sourceLine]; !ok || h < prev {
+			hits[path][s.sourceLine] = h
+		}
+	}
+
+	sources := map[string]formatters.Coverage{}
+	for path, lines := range hits {
+		last := 0
+		for l := range lines {
+			if l+1 > last {
+				last = l + 1
+			}
+		}
+		coverage := make(formatters.Coverage, last)
+		for l, h := range lines {
+			coverage[l] = formatters.NewNullInt(h)
+		}
+		sources[path] = coverage
+	}
+	return sources, nil
+}
+
+// source joins the source root of the map to one of its sources, with a
+// "/" between them if the root doesn't end in one.
+func (m sourceMap) source(s string) string {
+	if m.SourceRoot == "" || strings.HasSuffix(m.SourceRoot, "/") {
+		return m.SourceRoot + s
+	}
+	return m.SourceRoot + "/" + s
+}
+
+// resolveSource finds the path of a source named in a source map,
+// relative to base, the URL of the map. Sources that aren't files, such
+// as webpack:// ones, resolve to "".
+func resolveSource(source string, base string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	u, err := b.Parse(source)
+	if err != nil {
+		return ""
+	}
+	return filePath(u)
+}
@@ -0,0 +1,216 @@
+package v8
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// c8 keeps the raw coverage here before writing its reports
+var searchPaths = []string{"coverage/tmp"}
+
+// Formatter reads the raw V8 coverage Node writes when NODE_V8_COVERAGE is
+// set, either one file or a directory of them, one for each process.
+type Formatter struct {
+	Path    string
+	Options formatters.SourceFileOptions
+}
+
+// Search uses NODE_V8_COVERAGE if no paths are given.
+func (f *Formatter) Search(paths ...string) (string, error) {
+	if len(paths) == 0 {
+		if dir := envy.Get("NODE_V8_COVERAGE", ""); dir != "" {
+			paths = []string{dir}
+		}
+	}
+	paths = append(paths, searchPaths...)
+	for _, p := range paths {
+		logrus.Debugf("checking search path %s for v8 formatter", p)
+		files, err := coverageFiles(p)
+		if err == nil && len(files) > 0 {
+			f.Path = p
+			return p, nil
+		}
+	}
+
+	return "", errors.WithStack(errors.Errorf("could not find any files in search paths for v8. search paths were: %s", strings.Join(paths, ", ")))
+}
+
+// coverageFiles lists the JSON files in path if it's a directory.
+func coverageFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+type coverageRange struct {
+	StartOffset int `json:"startOffset"`
+	EndOffset   int `json:"endOffset"`
+	Count       int `json:"count"`
+}
+
+type script struct {
+	URL       string `json:"url"`
+	Functions []struct {
+		FunctionName string          `json:"functionName"`
+		Ranges       []coverageRange `json:"ranges"`
+	} `json:"functions"`
+}
+
+type coverageFile struct {
+	Result         []script                       `json:"result"`
+	SourceMapCache map[string]sourceMapCacheEntry `json:"source-map-cache"`
+}
+
+// Format reads the source of each script to turn the offsets of its
+// ranges into lines. Scripts with a source map are reported as the
+// sources they were generated from. Node's own scripts and those in
+// node_modules are left out.
+func (r Formatter) Format() (formatters.Report, error) {
+	rep, err := formatters.NewReport()
+	if err != nil {
+		return rep, err
+	}
+
+	files, err := coverageFiles(r.Path)
+	if err != nil {
+		return rep, err
+	}
+
+	gitHead, _ := env.GetHead()
+	for _, file := range files {
+		logrus.Debugf("reading V8 coverage %s", file)
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return rep, errors.WithStack(err)
+		}
+		cf := coverageFile{}
+		if err := json.Unmarshal(b, &cf); err != nil {
+			return rep, errors.Wrapf(err, "could not read %s", file)
+		}
+
+		for _, s := range cf.Result {
+			sfs, err := s.sourceFiles(cf.SourceMapCache[s.URL], gitHead, r.Options)
+			if err != nil {
+				return rep, errors.Wrapf(err, "could not read the coverage of %s in %s", s.URL, file)
+			}
+			for _, sf := range sfs {
+				err = rep.AddSourceFile(sf)
+				if err != nil {
+					return rep, errors.WithStack(err)
+				}
+			}
+		}
+	}
+
+	return rep, nil
+}
+
+func (s script) sourceFiles(sm sourceMapCacheEntry, gitHead *object.Commit, opts formatters.SourceFileOptions) ([]formatters.SourceFile, error) {
+	files := []formatters.SourceFile{}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return files, errors.WithStack(err)
+	}
+	path := filePath(u)
+	if path == "" || isDependency(path) {
+		logrus.Debugf("skipping script %s", s.URL)
+		return files, nil
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		logrus.Warnf("could not read the source of %s, leaving it out: %s", path, err)
+		return files, nil
+	}
+	lines := sourceLines(string(src))
+	ranges := []coverageRange{}
+	for _, fn := range s.Functions {
+		ranges = append(ranges, fn.Ranges...)
+	}
+	coverage := lineCoverage(lines, ranges)
+
+	if sm.Data != nil {
+		base := sm.URL
+		if base == "" {
+			base = s.URL
+		}
+		sources, err := sm.Data.apply(coverage, base)
+		if err != nil {
+			return files, err
+		}
+		names := []string{}
+		for name := range sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if isDependency(name) {
+				continue
+			}
+			sf, err := formatters.NewSourceFile(name, gitHead, opts)
+			if err != nil {
+				return files, errors.WithStack(err)
+			}
+			sf.Coverage = sources[name]
+			files = append(files, sf)
+		}
+		return files, nil
+	}
+
+	sf, err := formatters.NewSourceFile(path, gitHead, opts)
+	if err != nil {
+		return files, errors.WithStack(err)
+	}
+	sf.Coverage = coverage
+	for _, fn := range s.Functions {
+		// the script itself is a function without a name
+		if fn.FunctionName == "" || len(fn.Ranges) == 0 {
+			continue
+		}
+		sf.Functions = append(sf.Functions, formatters.Function{
+			Name:      fn.FunctionName,
+			StartLine: lineOf(lines, fn.Ranges[0].StartOffset),
+			EndLine:   lineOf(lines, fn.Ranges[0].EndOffset-1),
+			Hits:      fn.Ranges[0].Count,
+		})
+	}
+	return append(files, sf), nil
+}
+
+// filePath is the path of a file:// URL, or "" for any other URL.
+func filePath(u *url.URL) string {
+	if u.Scheme != "file" {
+		return ""
+	}
+	p := u.Path
+	// file:///C:/src/app.js
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+func isDependency(path string) bool {
+	return strings.Contains(filepath.ToSlash(path), "/node_modules/")
+}
@@ -0,0 +1,122 @@
+package v8
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	// the coverage names the scripts by their absolute file:// URLs
+	example, err := filepath.Abs("example")
+	r.NoError(err)
+	b, err := os.ReadFile("example/coverage/coverage-23486-1792198432512-0.json")
+	r.NoError(err)
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "coverage-1.json"), []byte(strings.Replace(string(b), "/EXAMPLE_DIR", filepath.ToSlash(example), -1)), 0644)
+	r.NoError(err)
+
+	f := &Formatter{}
+	_, err = f.Search(dir)
+	r.NoError(err)
+	rep, err := f.Format()
+	r.NoError(err)
+	// dist/greet.js is reported as the source it was generated from
+	r.Len(rep.SourceFiles, 2)
+
+	sf := rep.SourceFiles[filepath.Join(example, "src/math.js")]
+	r.Equal(formatters.Coverage{
+		formatters.NewNullInt(3),
+		formatters.NewNullInt(3),
+		formatters.NewNullInt(3),
+		formatters.NullInt{},
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(2),
+		formatters.NullInt{},
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(0),
+		formatters.NullInt{},
+		formatters.NewNullInt(1),
+	}, sf.Coverage)
+	r.Equal(formatters.Functions{
+		{Name: "add", StartLine: 1, EndLine: 3, Hits: 3},
+		{Name: "sign", StartLine: 5, EndLine: 8, Hits: 2},
+		{Name: "unused", StartLine: 10, EndLine: 12, Hits: 0},
+	}, sf.Functions)
+
+	sf = rep.SourceFiles[filepath.Join(example, "src/greet.ts")]
+	r.Equal(formatters.Coverage{
+		formatters.NewNullInt(1),
+		formatters.NewNullInt(1),
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(1),
+		formatters.NewNullInt(1),
+	}, sf.Coverage)
+}
+
+func Test_lineCoverage(t *testing.T) {
+	r := require.New(t)
+
+	// a block that never ran inside a function that ran twice
+	src := "function f(x) {\n  if (x) {\n    return 1;\n  }\n\n  return 2;\n}\n"
+	lines := sourceLines(src)
+	r.Len(lines, 7)
+	coverage := lineCoverage(lines, []coverageRange{
+		{StartOffset: 25, EndOffset: 44, Count: 0},
+		{StartOffset: 0, EndOffset: 60, Count: 2},
+	})
+	r.Equal(formatters.Coverage{
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(0),
+		formatters.NewNullInt(0),
+		formatters.NullInt{},
+		formatters.NewNullInt(2),
+		formatters.NewNullInt(2),
+	}, coverage)
+}
+
+func Test_decodeVLQ(t *testing.T) {
+	r := require.New(t)
+
+	values, err := decodeVLQ("AAgBC")
+	r.NoError(err)
+	r.Equal([]int{0, 0, 16, 1}, values)
+
+	values, err = decodeVLQ("D")
+	r.NoError(err)
+	r.Equal([]int{-1}, values)
+
+	_, err = decodeVLQ("g")
+	r.Error(err)
+}
+
+func Test_sourceMap_source(t *testing.T) {
+	r := require.New(t)
+
+	base := "file:///app/dist/index.js.map"
+	for _, root := range []string{"src", "src/"} {
+		m := sourceMap{SourceRoot: root}
+		r.Equal(filepath.FromSlash("/app/dist/src/foo.js"), resolveSource(m.source("foo.js"), base))
+	}
+	r.Equal(filepath.FromSlash("/app/src/foo.js"), resolveSource(sourceMap{}.source("../src/foo.js"), base))
+}
@@ -3,6 +3,8 @@ package xccov
 type sourceFile struct {
 	Path  string `json:"path"`
 	Functions []struct {
+		Name           string `json:"name"`
+		ExecutionCount int    `json:"executionCount"`
 		CoveredLines   int `json:"coveredLines"`
 		LineNumber int `json:"lineNumber"`
 		ExecutableLines int `json:"executableLines"`
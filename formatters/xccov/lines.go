@@ -0,0 +1,87 @@
+package xccov
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/codeclimate/test-reporter/formatters"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// lineCoverage is a line of the per-line report written by
+// "xcrun xccov view --archive --json", an object mapping each file's path
+// to its lines.
+type lineCoverage struct {
+	Line           int  `json:"line"`
+	IsExecutable   bool `json:"isExecutable"`
+	ExecutionCount int  `json:"executionCount"`
+}
+
+// IsLineCoverage reports whether the JSON file at path is a per-line xccov
+// report. Only the first line of the first file is read.
+func IsLineCoverage(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	d := json.NewDecoder(f)
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return false
+	}
+	if t, err := d.Token(); err != nil {
+		return false
+	} else if _, ok := t.(string); !ok {
+		return false
+	}
+	if t, err := d.Token(); err != nil || t != json.Delim('[') {
+		return false
+	}
+	line := map[string]interface{}{}
+	if err := d.Decode(&line); err != nil {
+		return false
+	}
+	_, hasLine := line["line"]
+	_, hasExecutable := line["isExecutable"]
+	return hasLine && hasExecutable
+}
+
+func (r Formatter) formatLines(report *formatters.Report, files map[string][]lineCoverage, gitHead *object.Commit) error {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		sourceFile, err := formatters.NewSourceFile(path, gitHead, r.Options)
+		if err != nil {
+			logrus.Warnf("Couldn't find file for path \"%s\" from %s coverage data. Ignore if the path doesn't correspond to an existent file in your repo.", path, r.Path)
+			continue
+		}
+
+		last := 0
+		for _, l := range files[path] {
+			if l.Line > last {
+				last = l.Line
+			}
+		}
+		sourceFile.Coverage = make(formatters.Coverage, last)
+		for _, l := range files[path] {
+			if l.Line < 1 || !l.IsExecutable {
+				continue
+			}
+			sourceFile.Coverage[l.Line-1] = formatters.NewNullInt(l.ExecutionCount)
+		}
+
+		err = report.AddSourceFile(sourceFile)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
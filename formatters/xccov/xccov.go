@@ -2,6 +2,7 @@ package xccov
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -13,8 +14,12 @@ import (
 
 var searchPaths = []string{"coverage.json"}
 
+// Formatter reads the JSON written by xccov, either the report of
+// "xcrun xccov view --report --json", which only has line counts for each
+// function, or the per-line coverage of "xcrun xccov view --archive --json".
 type Formatter struct {
-	Path string
+	Path    string
+	Options formatters.SourceFileOptions
 }
 
 func (f *Formatter) Search(paths ...string) (string, error) {
@@ -41,23 +46,53 @@ func (r Formatter) Format() (formatters.Report, error) {
 		return report, errors.WithStack(errors.Errorf("could not open coverage file %s", r.Path))
 	}
 
-	covFile := &xccovFile{}
-	err = json.NewDecoder(inputXccovFile).Decode(&covFile)
+	defer inputXccovFile.Close()
+
+	b, err := ioutil.ReadAll(inputXccovFile)
 	if err != nil {
 		return report, errors.WithStack(err)
 	}
 
 	gitHead, _ := env.GetHead()
+
+	// the per-line report maps each file to its lines, where the report of
+	// "xccov view --report --json" lists targets
+	document := map[string]json.RawMessage{}
+	err = json.Unmarshal(b, &document)
+	if err != nil {
+		return report, errors.WithStack(err)
+	}
+	if _, ok := document["targets"]; !ok {
+		files := map[string][]lineCoverage{}
+		err = json.Unmarshal(b, &files)
+		if err != nil {
+			return report, errors.Wrapf(err, "%s is neither an xccov report nor per-line xccov coverage", r.Path)
+		}
+		err = r.formatLines(&report, files, gitHead)
+		return report, err
+	}
+
+	covFile := &xccovFile{}
+	err = json.Unmarshal(b, &covFile)
+	if err != nil {
+		return report, errors.WithStack(err)
+	}
+
 	for _, target := range covFile.Targets {
 		for _, jsonFile := range target.Files {
 			num := 1
-			sourceFile, err := formatters.NewSourceFile(jsonFile.Path, gitHead)
+			sourceFile, err := formatters.NewSourceFile(jsonFile.Path, gitHead, r.Options)
 			if err != nil {
 				logrus.Warnf("Couldn't find file for path \"%s\" from %s coverage data. Ignore if the path doesn't correspond to an existent file in your repo.", jsonFile.Path, r.Path)
 				continue
 			}
 
 			for _, function := range jsonFile.Functions {
+				sourceFile.Functions = append(sourceFile.Functions, formatters.Function{
+					Name:      function.Name,
+					StartLine: function.LineNumber,
+					Hits:      function.ExecutionCount,
+				})
 				// fill non executable lines will null
 				for num < function.LineNumber {
 					sourceFile.Coverage = append(sourceFile.Coverage, formatters.NullInt{})
@@ -6,6 +6,7 @@ import (
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 
 	"github.com/codeclimate/test-reporter/env"
+	"github.com/codeclimate/test-reporter/formatters"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,6 +33,7 @@ func Test_Format(t *testing.T) {
 	r.Equal(sfLc.Covered, 0)
 	r.Equal(sfLc.Missed, 10)
 	r.Equal(sfLc.Total, 10)
+	r.Equal(formatters.Functions{{Name: "Some_function", StartLine: 3}}, sf.Functions)
 
 	sf = rep.SourceFiles["Documents/github/ww/ios-SuperApp/Pods/SuperClass/SuperClass/SuperClass.m"]
 	r.InDelta(sf.CoveredPercent, 22.68, 1)
@@ -39,4 +41,41 @@ func Test_Format(t *testing.T) {
 	r.Equal(sfLc.Covered, 27)
 	r.Equal(sfLc.Missed, 92)
 	r.Equal(sfLc.Total, 119)
-}
\ No newline at end of file
+}
+
+func Test_Format_Lines(t *testing.T) {
+	gb := env.GitBlob
+	defer func() { env.GitBlob = gb }()
+	env.GitBlob = func(s string, c *object.Commit) (string, error) {
+		return s, nil
+	}
+
+	r := require.New(t)
+
+	rb := Formatter{
+		Path: "./xccov_lines_example.json",
+	}
+	rep, err := rb.Format()
+	r.NoError(err)
+	r.Len(rep.SourceFiles, 2)
+
+	sf := rep.SourceFiles["/Users/ci/SuperApp/SuperApp/Calculator.swift"]
+	r.Len(sf.Coverage, 14)
+	r.False(sf.Coverage[0].Valid)
+	r.Equal(formatters.NewNullInt(4), sf.Coverage[3])
+	r.False(sf.Coverage[6].Valid)
+	r.Equal(formatters.NewNullInt(2), sf.Coverage[8])
+	r.Equal(formatters.NewNullInt(0), sf.Coverage[9])
+	r.Equal(9, sf.LineCounts.Total)
+	r.Equal(7, sf.LineCounts.Covered)
+
+	sf = rep.SourceFiles["/Users/ci/SuperApp/SuperApp/AppDelegate.swift"]
+	r.Equal(2, sf.LineCounts.Missed)
+}
+
+func Test_IsLineCoverage(t *testing.T) {
+	r := require.New(t)
+
+	r.True(IsLineCoverage("./xccov_lines_example.json"))
+	r.False(IsLineCoverage("./xccov_example.json"))
+}
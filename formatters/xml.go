@@ -0,0 +1,61 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// XMLHandler is called with the decoder and each xml.StartElement or
+// xml.EndElement of a document read by StreamXML. Decoding a start
+// element with d.DecodeElement consumes it up to and including its end
+// element, which the handler then won't see.
+type XMLHandler func(d *xml.Decoder, t xml.Token) error
+
+// StreamXML reads an XML document one element at a time, so only the
+// elements the handler decodes are ever held in memory. It fails if the
+// document's root element isn't named root.
+func StreamXML(r io.Reader, root string, handle XMLHandler) error {
+	d := xml.NewDecoder(r)
+	seenRoot := false
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			if !seenRoot {
+				return errors.Errorf("expected element type <%s> but found none", root)
+			}
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		switch el := t.(type) {
+		case xml.StartElement:
+			if !seenRoot {
+				if el.Name.Local != root {
+					return errors.Errorf("expected element type <%s> but have <%s>", root, el.Name.Local)
+				}
+				seenRoot = true
+			}
+		case xml.EndElement:
+		default:
+			continue
+		}
+
+		if err := handle(d, t); err != nil {
+			return err
+		}
+	}
+}
+
+// XMLAttr returns the value of the named attribute of a start element.
+func XMLAttr(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StreamXML(t *testing.T) {
+	r := require.New(t)
+
+	doc := `<?xml version="1.0"?>
+<coverage>
+  <package name="a"><file name="one.go"/></package>
+  <package name="b"><file name="two.go"/></package>
+</coverage>`
+
+	type file struct {
+		Name string `xml:"name,attr"`
+	}
+
+	events := []string{}
+	err := StreamXML(strings.NewReader(doc), "coverage", func(d *xml.Decoder, t xml.Token) error {
+		switch el := t.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "package":
+				events = append(events, "start "+XMLAttr(el, "name"))
+			case "file":
+				f := file{}
+				if err := d.DecodeElement(&f, &el); err != nil {
+					return err
+				}
+				events = append(events, "file "+f.Name)
+			}
+		case xml.EndElement:
+			if el.Name.Local == "package" {
+				events = append(events, "end")
+			}
+		}
+		return nil
+	})
+	r.NoError(err)
+	r.Equal([]string{"start a", "file one.go", "end", "start b", "file two.go", "end"}, events)
+}
+
+func Test_StreamXML_WrongRoot(t *testing.T) {
+	r := require.New(t)
+
+	err := StreamXML(strings.NewReader("<report></report>"), "coverage", func(*xml.Decoder, xml.Token) error {
+		return nil
+	})
+	r.Error(err)
+	r.Equal("expected element type <coverage> but have <report>", err.Error())
+
+	err = StreamXML(strings.NewReader(""), "coverage", func(*xml.Decoder, xml.Token) error {
+		return nil
+	})
+	r.Error(err)
+}
@@ -11,6 +11,7 @@ require (
 	github.com/stretchr/testify v1.8.0
 	golang.org/x/tools v0.0.0-20170428054726-2382e3994d48
 	gopkg.in/src-d/go-git.v4 v4.0.0-rc9.0.20170328035333-36c78b9d1b1e
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -31,5 +32,4 @@ require (
 	gopkg.in/src-d/go-billy.v2 v2.0.4 // indirect
 	gopkg.in/src-d/go-billy.v4 v4.3.2 // indirect
 	gopkg.in/warnings.v0 v0.1.1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
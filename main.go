@@ -14,8 +14,16 @@
 
 package main
 
-import "github.com/codeclimate/test-reporter/cmd"
+import (
+	_ "embed"
+
+	"github.com/codeclimate/test-reporter/cmd"
+)
+
+//go:embed schema.json
+var schema []byte
 
 func main() {
+	cmd.ReportSchema = schema
 	cmd.Execute()
 }
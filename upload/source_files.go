@@ -5,11 +5,14 @@ import (
 )
 
 type SourceFile struct {
-	Type            string                `json:"type"`
-	BlobID          string                `json:"blob_id"`
-	Coverage        formatters.Coverage   `json:"coverage"`
-	CoveredPercent  float64               `json:"covered_percent"`
-	CoveredStrength float64               `json:"covered_strength"`
-	LineCounts      formatters.LineCounts `json:"line_counts"`
-	Path            string                `json:"path"`
+	Type                 string                `json:"type"`
+	BlobID               string                `json:"blob_id"`
+	Coverage             formatters.Coverage   `json:"coverage"`
+	CoveredPercent       float64               `json:"covered_percent"`
+	CoveredStrength      float64               `json:"covered_strength"`
+	LineCounts           formatters.LineCounts `json:"line_counts"`
+	Branches             formatters.Branches   `json:"branches,omitempty"`
+	BranchCounts         formatters.LineCounts `json:"branch_counts"`
+	BranchCoveredPercent float64               `json:"branch_covered_percent"`
+	Path                 string                `json:"path"`
 }
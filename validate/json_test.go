@@ -0,0 +1,26 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Relax(t *testing.T) {
+	r := require.New(t)
+
+	relaxed := `{
+  "a": "http://example.com", // a comment
+  "b": [1, 2, ],
+  "c": "a \"quoted\" // not a comment,",
+  "d": { "e": true, // trailing
+  },
+}`
+	doc := map[string]interface{}{}
+	r.NoError(json.Unmarshal(Relax([]byte(relaxed)), &doc))
+	r.Equal("http://example.com", doc["a"])
+	r.Equal([]interface{}{1.0, 2.0}, doc["b"])
+	r.Equal(`a "quoted" // not a comment,`, doc["c"])
+	r.Equal(map[string]interface{}{"e": true}, doc["d"])
+}
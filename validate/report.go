@@ -0,0 +1,137 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+var blobID = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// how far a covered percent may be from the one its line counts give,
+// to allow for rounding by other tools
+const percentTolerance = 0.01
+
+// Report checks a coverage report, as written by format-coverage or
+// sum-coverage, against the schema and the invariants the schema can't
+// express: the line counts match the coverage, the covered percents match
+// the line counts, file names are unique and blob ids are git shas.
+func Report(data []byte, schema *Schema) []Problem {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []Problem{{Path: "$", Message: fmt.Sprintf("is not valid JSON: %s", err)}}
+	}
+
+	problems := schema.Validate(doc)
+	return append(problems, checkReport(doc)...)
+}
+
+type lineTotals struct {
+	covered, missed, total float64
+}
+
+func checkReport(doc interface{}) []Problem {
+	problems := []Problem{}
+	add := func(path string, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	report, ok := doc.(map[string]interface{})
+	if !ok {
+		return problems
+	}
+	files, _ := report["source_files"].([]interface{})
+
+	names := map[string]int{}
+	sum := lineTotals{}
+	sumValid := true
+	for i, f := range files {
+		path := fmt.Sprintf("$.source_files[%d]", i)
+		file, ok := f.(map[string]interface{})
+		if !ok {
+			sumValid = false
+			continue
+		}
+
+		if name, ok := file["name"].(string); ok {
+			if j, dup := names[name]; dup {
+				add(path+".name", "%q is also the name of $.source_files[%d]", name, j)
+			} else {
+				names[name] = i
+			}
+		}
+
+		if blob, ok := file["blob_id"].(string); ok && !blobID.MatchString(blob) {
+			add(path+".blob_id", "%q is not a 40 character hex git blob id", blob)
+		}
+
+		coverage, ok := file["coverage"].(string)
+		if !ok {
+			sumValid = false
+			continue
+		}
+		counts, err := coverageTotals(coverage)
+		if err != nil {
+			add(path+".coverage", "could not be parsed: %s", err)
+			sumValid = false
+			continue
+		}
+		sum.covered += counts.covered
+		sum.missed += counts.missed
+		sum.total += counts.total
+
+		checkCounts(file, counts, path, add)
+	}
+
+	if sumValid {
+		checkCounts(report, sum, "$", add)
+	}
+	return problems
+}
+
+// checkCounts compares the line_counts and covered_percent of obj with
+// the counts it should have.
+func checkCounts(obj map[string]interface{}, want lineTotals, path string, add func(string, string, ...interface{})) {
+	lineCounts, ok := obj["line_counts"].(map[string]interface{})
+	if ok {
+		for _, c := range []struct {
+			key  string
+			want float64
+		}{{"covered", want.covered}, {"missed", want.missed}, {"total", want.total}} {
+			if got, ok := lineCounts[c.key].(float64); ok && got != c.want {
+				add(path+".line_counts."+c.key, "is %v, but the coverage counts %v", got, c.want)
+			}
+		}
+	}
+
+	wantPercent := 0.0
+	if want.total > 0 {
+		wantPercent = want.covered / want.total * 100
+	}
+	if got, ok := obj["covered_percent"].(float64); ok && math.Abs(got-wantPercent) > percentTolerance {
+		add(path+".covered_percent", "is %v, but the coverage gives %.2f", got, wantPercent)
+	}
+}
+
+// coverageTotals counts the lines of a coverage string, such as
+// "[1,0,null]", the way SourceFile.CalcLineCounts does.
+func coverageTotals(coverage string) (lineTotals, error) {
+	counts := lineTotals{}
+	lines := []*float64{}
+	if err := json.Unmarshal([]byte(coverage), &lines); err != nil {
+		return counts, err
+	}
+	for _, l := range lines {
+		if l == nil {
+			continue
+		}
+		counts.total++
+		if *l == 0 {
+			counts.missed++
+			continue
+		}
+		counts.covered++
+	}
+	return counts, nil
+}
@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadSchema(t *testing.T) *Schema {
+	b, err := os.ReadFile("../schema.json")
+	require.NoError(t, err)
+	s, err := ParseSchema(b)
+	require.NoError(t, err)
+	return s
+}
+
+func Test_Report_Valid(t *testing.T) {
+	r := require.New(t)
+	s := loadSchema(t)
+
+	files, err := filepath.Glob("../integration-tests/codeclimate*.json")
+	r.NoError(err)
+	r.NotEmpty(files)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		r.NoError(err)
+		r.Empty(Report(b, s), f)
+	}
+}
+
+func Test_Report_Problems(t *testing.T) {
+	r := require.New(t)
+	s := loadSchema(t)
+
+	b, err := os.ReadFile("../integration-tests/codeclimate.json")
+	r.NoError(err)
+	doc := map[string]interface{}{}
+	r.NoError(json.Unmarshal(b, &doc))
+
+	files := doc["source_files"].([]interface{})
+	first := files[0].(map[string]interface{})
+	first["line_counts"].(map[string]interface{})["covered"] = 1.0
+	first["covered_percent"] = 42.0
+	second := files[1].(map[string]interface{})
+	second["blob_id"] = "ABCDEF"
+	second["name"] = first["name"]
+	delete(doc, "git")
+
+	b, err = json.Marshal(doc)
+	r.NoError(err)
+
+	messages := []string{}
+	for _, p := range Report(b, s) {
+		messages = append(messages, p.String())
+	}
+	r.Contains(messages, "$.git: is required")
+	r.Contains(messages, `$.source_files[1].blob_id: "ABCDEF" does not match ^[a-zA-Z0-9]{40}$`)
+	r.Contains(messages, `$.source_files[1].blob_id: "ABCDEF" is not a 40 character hex git blob id`)
+	r.Contains(messages, `$.source_files[1].name: "lib/code_climate/test_reporter/git.rb" is also the name of $.source_files[0]`)
+	r.Contains(messages, "$.source_files[0].line_counts.covered: is 1, but the coverage counts 43")
+	r.Contains(messages, "$.source_files[0].covered_percent: is 42, but the coverage gives 100.00")
+}
+
+func Test_Report_Invalid_JSON(t *testing.T) {
+	r := require.New(t)
+
+	problems := Report([]byte(`{"source_files": [`), loadSchema(t))
+	r.Len(problems, 1)
+	r.Equal("$", problems[0].Path)
+}
+
+func Test_Schema_Types(t *testing.T) {
+	r := require.New(t)
+
+	s, err := ParseSchema([]byte(`{
+  "type": "object",
+  "properties": {
+    "count": { "type": "integer", "minimum": 0 },
+    "kind": { "enum": ["a", "b"] },
+  },
+  "additionalProperties": false,
+}`))
+	r.NoError(err)
+
+	var doc interface{}
+	r.NoError(json.Unmarshal([]byte(`{"count": 1.5, "kind": "c", "extra": null}`), &doc))
+	problems := s.Validate(doc)
+	r.Equal([]Problem{
+		{Path: "$.count", Message: "expected integer, found number"},
+		{Path: "$.extra", Message: "is not allowed"},
+		{Path: "$.kind", Message: `"c" is not one of the allowed values`},
+	}, problems)
+}
@@ -0,0 +1,264 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Problem is something wrong with a document, found at Path, a JSON path
+// such as "$.source_files[2].blob_id".
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// Schema checks documents against a JSON schema. It understands the parts
+// of JSON Schema draft 4 that schema.json uses: type, enum, $ref, pattern,
+// minimum, maximum, required, properties, additionalProperties, items,
+// minItems, maxItems and uniqueItems.
+type Schema struct {
+	root     map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ParseSchema parses a schema written in relaxed JSON.
+func ParseSchema(b []byte) (*Schema, error) {
+	root := map[string]interface{}{}
+	if err := json.Unmarshal(Relax(b), &root); err != nil {
+		return nil, errors.Wrap(err, "could not parse the schema")
+	}
+	return &Schema{root: root, patterns: map[string]*regexp.Regexp{}}, nil
+}
+
+// Validate returns the problems found checking doc, as decoded by
+// encoding/json into an interface{}, against the schema.
+func (s *Schema) Validate(doc interface{}) []Problem {
+	problems := []Problem{}
+	s.validate(s.root, doc, "$", &problems)
+	return problems
+}
+
+func (s *Schema) resolve(node map[string]interface{}) (map[string]interface{}, error) {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, errors.Errorf("unsupported $ref %s", ref)
+	}
+	var cur interface{} = s.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("could not resolve $ref %s", ref)
+		}
+		cur = m[part]
+	}
+	resolved, ok := cur.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("could not resolve $ref %s", ref)
+	}
+	return s.resolve(resolved)
+}
+
+func (s *Schema) validate(node map[string]interface{}, v interface{}, path string, problems *[]Problem) {
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	node, err := s.resolve(node)
+	if err != nil {
+		add("%s", err)
+		return
+	}
+
+	if t, ok := node["type"]; ok && !hasType(t, v) {
+		add("expected %s, found %s", typeNames(t), jsonType(v))
+		return
+	}
+
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("%s is not one of the allowed values", short(v))
+		}
+	}
+
+	switch vv := v.(type) {
+	case string:
+		if p, ok := node["pattern"].(string); ok {
+			re, err := s.pattern(p)
+			if err != nil {
+				add("invalid pattern %q in schema", p)
+			} else if !re.MatchString(vv) {
+				add("%s does not match %s", short(vv), p)
+			}
+		}
+	case float64:
+		if min, ok := node["minimum"].(float64); ok && vv < min {
+			add("%v is less than the minimum of %v", vv, min)
+		}
+		if max, ok := node["maximum"].(float64); ok && vv > max {
+			add("%v is more than the maximum of %v", vv, max)
+		}
+	case []interface{}:
+		if min, ok := node["minItems"].(float64); ok && float64(len(vv)) < min {
+			add("has %d items, expected at least %v", len(vv), min)
+		}
+		if max, ok := node["maxItems"].(float64); ok && float64(len(vv)) > max {
+			add("has %d items, expected at most %v", len(vv), max)
+		}
+		if unique, _ := node["uniqueItems"].(bool); unique {
+			seen := map[string]int{}
+			for i, item := range vv {
+				b, _ := json.Marshal(item)
+				if j, ok := seen[string(b)]; ok {
+					add("items %d and %d are the same", j, i)
+					continue
+				}
+				seen[string(b)] = i
+			}
+		}
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range vv {
+				s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := node["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := vv[name]; !ok {
+					*problems = append(*problems, Problem{Path: childPath(path, name), Message: "is required"})
+				}
+			}
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := properties[k].(map[string]interface{}); ok {
+				s.validate(p, vv[k], childPath(path, k), problems)
+				continue
+			}
+			switch additional := node["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*problems = append(*problems, Problem{Path: childPath(path, k), Message: "is not allowed"})
+				}
+			case map[string]interface{}:
+				s.validate(additional, vv[k], childPath(path, k), problems)
+			}
+		}
+	}
+}
+
+func (s *Schema) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns[p]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[p] = re
+	return re, nil
+}
+
+func hasType(t interface{}, v interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(tt, v)
+	case []interface{}:
+		for _, name := range tt {
+			if n, ok := name.(string); ok && isType(n, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v interface{}) bool {
+	actual := jsonType(v)
+	if name == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return name == actual
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		s := []string{}
+		for _, n := range names {
+			s = append(s, fmt.Sprint(n))
+		}
+		return strings.Join(s, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func equal(a, b interface{}) bool {
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return string(ab) == string(bb)
+}
+
+// short quotes a value for a message, cutting long ones such as coverage
+// arrays down to size.
+func short(v interface{}) string {
+	b, _ := json.Marshal(v)
+	if len(b) > 60 {
+		return string(b[:57]) + "..."
+	}
+	return string(b)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func childPath(path, key string) string {
+	if identifier.MatchString(key) {
+		return path + "." + key
+	}
+	b, _ := json.Marshal(key)
+	return fmt.Sprintf("%s[%s]", path, b)
+}